@@ -0,0 +1,603 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Odpowiedzi API w formacie JSON. Rodzaje paliwa, typy pojazdów i sposoby
+// płatności mają te same nazwy co w plikach scenariuszy.
+
+type statusResponse struct {
+	Time     time.Time          `json:"time"`
+	Running  bool               `json:"running"`
+	Pumps    []pumpStatus       `json:"pumps"`
+	Queue    queueStatus        `json:"queue"`
+	Checkout checkoutStatus     `json:"checkout"`
+	Prices   map[string]float64 `json:"prices"`
+	Pricing  string             `json:"pricing"` // strategia cenowa
+	Services *servicesStatus    `json:"services,omitempty"`
+	Schedule *scheduleStatus    `json:"schedule,omitempty"`
+	Stats    statsStatus        `json:"stats"`
+}
+
+type pumpStatus struct {
+	ID          int            `json:"id"`
+	Fuels       []string       `json:"fuels"`
+	FlowRate    float64        `json:"flow_rate"` // litry na sekundę
+	Vehicles    []string       `json:"vehicles"`
+	State       string         `json:"state"` // free, busy, paying, broken, maintenance, closed, off_shift
+	Vehicle     *vehicleStatus `json:"vehicle,omitempty"`
+	Served      int            `json:"served"`
+	Dispensed   float64        `json:"dispensed"` // litry
+	BusySeconds float64        `json:"busy_seconds"`
+	Failures    int            `json:"failures"`
+	DownSeconds float64        `json:"down_seconds"`
+}
+
+type vehicleStatus struct {
+	ID         int     `json:"id"`
+	Type       string  `json:"type"`
+	Fuel       string  `json:"fuel"`
+	FuelAmount float64 `json:"fuel_amount"`
+	Order      string  `json:"order"`
+	MoneyLimit float64 `json:"money_limit,omitempty"`
+	Dispensed  float64 `json:"dispensed"` // litry zatankowane dotąd
+	Cost       float64 `json:"cost"`      // kwota za zatankowane paliwo
+	Payment    string  `json:"payment"`
+	Priority   string  `json:"priority"`
+}
+
+type queueStatus struct {
+	Total int            `json:"total"`
+	Lines map[string]int `json:"lines"`
+}
+
+type checkoutStatus struct {
+	Cashiers int `json:"cashiers"`
+	OnDuty   int `json:"on_duty"` // kasy obsadzone na bieżącej zmianie
+	Busy     int `json:"busy"`
+	Waiting  int `json:"waiting"`
+}
+
+// scheduleStatus opisuje godziny otwarcia i bieżącą zmianę, gdy scenariusz
+// ma harmonogram
+type scheduleStatus struct {
+	Open   bool          `json:"open"`
+	Shift  string        `json:"shift,omitempty"`
+	Shifts []shiftStatus `json:"shifts,omitempty"`
+}
+
+type shiftStatus struct {
+	Name                       string  `json:"name"`
+	Start                      string  `json:"start"`
+	Cashiers                   int     `json:"cashiers"`
+	Pumps                      []int   `json:"pumps,omitempty"`
+	Arrivals                   int     `json:"arrivals"`
+	Served                     int     `json:"served"`
+	Lost                       int     `json:"lost"`
+	Closed                     int     `json:"closed"`
+	Revenue                    float64 `json:"revenue"`
+	AverageWaitSeconds         float64 `json:"average_wait_seconds"`
+	AverageCheckoutWaitSeconds float64 `json:"average_checkout_wait_seconds"`
+}
+
+// servicesStatus opisuje sklep i myjnię, gdy kierowcy z nich korzystają
+type servicesStatus struct {
+	Shop stageStatus `json:"shop"`
+	Wash stageStatus `json:"wash"`
+}
+
+type stageStatus struct {
+	Servers            int     `json:"servers"`
+	Busy               *int    `json:"busy,omitempty"` // tylko myjnia; kasy podaje checkout
+	Waiting            *int    `json:"waiting,omitempty"`
+	Arrivals           int     `json:"arrivals"`
+	Served             int     `json:"served"`
+	Skipped            int     `json:"skipped"`
+	Revenue            float64 `json:"revenue"`
+	AverageWaitSeconds float64 `json:"average_wait_seconds"`
+}
+
+// stageStatusOf opisuje statystyki etapu. Wywoływana z zablokowanym
+// mutexem statystyk.
+func stageStatusOf(s *StageStatistics, servers int) stageStatus {
+	st := stageStatus{
+		Servers:  servers,
+		Arrivals: s.Arrivals,
+		Served:   s.Served,
+		Skipped:  s.Skipped,
+		Revenue:  s.Revenue,
+	}
+	if n := s.Wait.Count(); n > 0 {
+		st.AverageWaitSeconds = (s.Wait.Sum() / time.Duration(n)).Seconds()
+	}
+	return st
+}
+
+type statsStatus struct {
+	TotalVehicles      int                    `json:"total_vehicles"`
+	ServedVehicles     int                    `json:"served_vehicles"`
+	BalkedVehicles     int                    `json:"balked_vehicles"`
+	RenegedVehicles    int                    `json:"reneged_vehicles"`
+	TurnedAway         int                    `json:"turned_away"`
+	PriceBalked        int                    `json:"price_balked"`
+	ClosedVehicles     int                    `json:"closed_vehicles"`
+	InterruptedRefuels int                    `json:"interrupted_refuels"`
+	LimitedRefuels     int                    `json:"limited_refuels"`
+	AbortedRefuels     int                    `json:"aborted_refuels"`
+	FuelDispensed      float64                `json:"fuel_dispensed"`
+	Revenue            float64                `json:"revenue"`
+	LostRevenue        float64                `json:"lost_revenue"`
+	AverageWaitSeconds float64                `json:"average_wait_seconds"`
+	Fuel               map[string]fuelStatus  `json:"fuel"`
+	Orders             map[string]orderStatus `json:"orders"`
+}
+
+type orderStatus struct {
+	Served  int     `json:"served"`
+	Liters  float64 `json:"liters"`
+	Revenue float64 `json:"revenue"`
+}
+
+type fuelStatus struct {
+	TankLevel   float64 `json:"tank_level"`
+	Dispensed   float64 `json:"dispensed"`
+	StockOuts   int     `json:"stock_outs"`
+	Refills     int     `json:"refills"`
+	LostSales   int     `json:"lost_sales"`
+	LostRevenue float64 `json:"lost_revenue"`
+}
+
+// vehicleRequest to pojazd dodawany przez POST /vehicles. Pominięte
+// pola opcjonalne biorą wartości domyślne dla typu pojazdu.
+type vehicleRequest struct {
+	Type           string  `json:"type"`
+	Fuel           string  `json:"fuel"`
+	FuelAmount     float64 `json:"fuel_amount"`
+	Order          string  `json:"order,omitempty"`
+	MoneyLimit     float64 `json:"money_limit,omitempty"`
+	TankCapacity   float64 `json:"tank_capacity,omitempty"`
+	TankLevel      float64 `json:"tank_level,omitempty"`
+	Payment        string  `json:"payment,omitempty"`
+	WaitsForRefill bool    `json:"waits_for_refill,omitempty"`
+	QueueTolerance *int    `json:"queue_tolerance,omitempty"`
+	Patience       string  `json:"patience,omitempty"`
+	Priority       string  `json:"priority,omitempty"`
+}
+
+type vehicleResponse struct {
+	ID     int  `json:"id"`
+	Queued bool `json:"queued"` // false - kierowca odjechał od razu
+}
+
+type errorResponse struct {
+	Error   string   `json:"error"`
+	Details []string `json:"details,omitempty"`
+}
+
+// NewAPI zwraca handler HTTP do podglądu i sterowania stacją:
+//
+//	GET  /status            stan dystrybutorów, kolejek i statystyki
+//	GET  /events            strumień zdarzeń (Server-Sent Events)
+//	GET  /metrics           metryki w formacie Prometheusa
+//	POST /vehicles          dodanie pojazdu
+//	POST /pumps/{id}/close  wyłączenie dystrybutora
+//	POST /pumps/{id}/open   ponowne włączenie dystrybutora
+//	PUT  /prices            zmiana cen paliwa
+func NewAPI(gs *GasStation) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", gs.handleStatus)
+	mux.HandleFunc("GET /events", gs.handleEvents)
+	mux.HandleFunc("GET /metrics", gs.handleMetrics)
+	mux.HandleFunc("POST /vehicles", gs.handleAddVehicle)
+	mux.HandleFunc("POST /pumps/{id}/close", func(w http.ResponseWriter, r *http.Request) {
+		gs.handleSetPumpOpen(w, r, false)
+	})
+	mux.HandleFunc("POST /pumps/{id}/open", func(w http.ResponseWriter, r *http.Request) {
+		gs.handleSetPumpOpen(w, r, true)
+	})
+	mux.HandleFunc("PUT /prices", gs.handleSetPrices)
+	return mux
+}
+
+// ServeAPI uruchamia serwer API na adresie addr. Serwer działa do
+// wywołania Shutdown.
+func ServeAPI(gs *GasStation, addr string) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	server := &http.Server{
+		Handler:           NewAPI(gs),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go server.Serve(listener)
+	return server, nil
+}
+
+func (gs *GasStation) handleStatus(w http.ResponseWriter, r *http.Request) {
+	status := statusResponse{
+		Time:    gs.Clock.Now(),
+		Running: gs.isRunning(),
+		Queue:   queueStatus{Total: gs.Queue.Len(), Lines: make(map[string]int)},
+		Prices:  make(map[string]float64),
+		Pricing: gs.Pricing.Policy.Strategy,
+	}
+
+	for _, pump := range gs.Pumps {
+		status.Pumps = append(status.Pumps, pumpStatusOf(pump, status.Time))
+	}
+	for _, ft := range allFuelTypes {
+		status.Queue.Lines[fuelTypeKey(ft)] = gs.Queue.LineLen(ft)
+	}
+	status.Checkout.Cashiers = gs.Checkout.NumCashiers
+	status.Checkout.OnDuty = gs.Checkout.OnDuty()
+	status.Checkout.Busy, status.Checkout.Waiting = gs.Checkout.Status()
+
+	for ft, price := range gs.Pricing.Prices() {
+		status.Prices[fuelTypeKey(ft)] = price
+	}
+
+	levels := make(map[FuelType]float64)
+	for _, ft := range allFuelTypes {
+		tank := gs.Tanks[ft]
+		tank.mutex.Lock()
+		levels[ft] = tank.Level
+		tank.mutex.Unlock()
+	}
+
+	open, shift := gs.isOpen(), gs.currentShift()
+
+	gs.Stats.mutex.RLock()
+	status.Stats = statsStatus{
+		TotalVehicles:      gs.Stats.TotalVehicles,
+		ServedVehicles:     gs.Stats.ServedVehicles,
+		BalkedVehicles:     gs.Stats.BalkedVehicles,
+		RenegedVehicles:    gs.Stats.RenegedVehicles,
+		TurnedAway:         gs.Stats.TurnedAway,
+		PriceBalked:        gs.Stats.PriceBalked,
+		ClosedVehicles:     gs.Stats.ClosedVehicles,
+		InterruptedRefuels: gs.Stats.InterruptedRefuels,
+		LimitedRefuels:     gs.Stats.LimitedRefuels,
+		AbortedRefuels:     gs.Stats.AbortedRefuels,
+		FuelDispensed:      gs.Stats.TotalFuelDispensed,
+		Revenue:            gs.Stats.TotalRevenue,
+		LostRevenue:        gs.Stats.LostRevenue,
+		AverageWaitSeconds: gs.Stats.AverageWaitTime.Seconds(),
+		Fuel:               make(map[string]fuelStatus),
+		Orders:             make(map[string]orderStatus),
+	}
+	for _, ft := range allFuelTypes {
+		fs := gs.Stats.Fuel[ft]
+		status.Stats.Fuel[fuelTypeKey(ft)] = fuelStatus{
+			TankLevel:   levels[ft],
+			Dispensed:   fs.Dispensed,
+			StockOuts:   fs.StockOuts,
+			Refills:     fs.Refills,
+			LostSales:   fs.LostSales,
+			LostRevenue: fs.LostRevenue,
+		}
+	}
+	for _, m := range allOrderModes {
+		st := gs.Stats.Orders[m]
+		status.Stats.Orders[nameOf(orderModeNames, m)] = orderStatus{Served: st.Served, Liters: st.Liters, Revenue: st.Revenue}
+	}
+	if gs.servicesEnabled() {
+		status.Services = &servicesStatus{
+			Shop: stageStatusOf(gs.Stats.Shop, gs.Checkout.NumCashiers),
+			Wash: stageStatusOf(gs.Stats.Wash, gs.Wash.Bays),
+		}
+	}
+	if schedule := gs.Scenario.Schedule; schedule.enabled() {
+		status.Schedule = &scheduleStatus{Open: open}
+		if shift >= 0 {
+			status.Schedule.Shift = schedule.Shifts[shift].Name
+		}
+		for i, shift := range schedule.Shifts {
+			s := gs.Stats.Shifts[i]
+			st := shiftStatus{
+				Name:     shift.Name,
+				Start:    formatTimeOfDay(shift.Start),
+				Cashiers: shift.Cashiers,
+				Pumps:    shift.Pumps,
+				Arrivals: s.Arrivals,
+				Served:   s.Served,
+				Lost:     s.Lost,
+				Closed:   s.Closed,
+				Revenue:  s.Revenue,
+			}
+			if s.Served > 0 {
+				st.AverageWaitSeconds = (s.Wait.Sum() / time.Duration(s.Served)).Seconds()
+				st.AverageCheckoutWaitSeconds = (s.CheckoutWait / time.Duration(s.Served)).Seconds()
+			}
+			status.Schedule.Shifts = append(status.Schedule.Shifts, st)
+		}
+	}
+	gs.Stats.mutex.RUnlock()
+
+	if status.Services != nil {
+		busy, waiting := gs.Wash.Status()
+		status.Services.Wash.Busy, status.Services.Wash.Waiting = &busy, &waiting
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+// pumpStatusOf opisuje stan dystrybutora w chwili now
+func pumpStatusOf(pump *Pump, now time.Time) pumpStatus {
+	down := pump.Downtime(now)
+	pump.mutex.Lock()
+	defer pump.mutex.Unlock()
+
+	ps := pumpStatus{
+		ID:          pump.ID,
+		FlowRate:    pump.FlowRate,
+		State:       "free",
+		Served:      pump.Served,
+		Dispensed:   pump.Dispensed,
+		BusySeconds: pump.BusyTime.Seconds(),
+		Failures:    pump.Failures,
+		DownSeconds: down.Seconds(),
+	}
+	for _, ft := range pump.FuelTypes {
+		ps.Fuels = append(ps.Fuels, fuelTypeKey(ft))
+	}
+	for _, vt := range pump.Vehicles {
+		ps.Vehicles = append(ps.Vehicles, nameOf(vehicleTypeNames, vt))
+	}
+	switch {
+	case pump.Paying:
+		ps.State = "paying"
+	case pump.IsOccupied:
+		ps.State = "busy"
+	case pump.Broken:
+		ps.State = "broken"
+	case pump.InMaintenance:
+		ps.State = "maintenance"
+	case pump.Closed:
+		ps.State = "closed"
+	case pump.OffShift:
+		ps.State = "off_shift"
+	}
+	if v := pump.CurrentVehicle; v != nil {
+		ps.Vehicle = &vehicleStatus{
+			ID:         v.ID,
+			Type:       nameOf(vehicleTypeNames, v.Type),
+			Fuel:       fuelTypeKey(v.FuelType),
+			FuelAmount: v.FuelAmount,
+			Order:      nameOf(orderModeNames, v.Order),
+			MoneyLimit: v.MoneyLimit,
+			Dispensed:  pump.CurrentLiters,
+			Cost:       pump.CurrentCost,
+			Payment:    nameOf(paymentMethodNames, v.Payment),
+			Priority:   nameOf(priorityNames, v.Priority),
+		}
+	}
+	return ps
+}
+
+// Bufor zdarzeń jednego klienta strumienia; wolniejszy klient traci
+// zdarzenia zamiast spowalniać stację
+const eventBuffer = 256
+
+// handleEvents wysyła zdarzenia stacji jako Server-Sent Events. Zdarzenia
+// pominięte z powodu pełnego bufora zgłaszane są zdarzeniem "dropped".
+func (gs *GasStation) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "serwer nie obsługuje strumieniowania")
+		return
+	}
+
+	sub := gs.Events.Subscribe(eventBuffer)
+	defer gs.Events.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if n := sub.TakeDropped(); n > 0 {
+				if _, err := fmt.Fprintf(w, "event: dropped\ndata: {\"dropped\":%d}\n\n", n); err != nil {
+					return
+				}
+			}
+			data, _ := json.Marshal(e)
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.Seq, e.Kind, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (gs *GasStation) handleAddVehicle(w http.ResponseWriter, r *http.Request) {
+	var req vehicleRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	vehicle, err := req.build(gs.Scenario.TankCapacity)
+	if err != nil {
+		writeValidationError(w, "niepoprawny pojazd", err)
+		return
+	}
+	if !gs.isRunning() {
+		writeError(w, http.StatusServiceUnavailable, "stacja jest zatrzymana")
+		return
+	}
+
+	queued := gs.AddVehicle(vehicle)
+	writeJSON(w, http.StatusAccepted, vehicleResponse{ID: vehicle.ID, Queued: queued})
+}
+
+// build sprawdza zgłoszenie i tworzy z niego pojazd. Pojazd nie może
+// zamówić więcej paliwa ani mieć większego baku, niż mieści zbiornik
+// stacji o pojemności tankCapacity - czekałby na dostawę bez końca.
+func (req vehicleRequest) build(tankCapacity float64) (*Vehicle, error) {
+	var errs scenarioErrors
+
+	vt, ok := vehicleTypeNames[req.Type]
+	if !ok {
+		errs.add("type", "nieznany typ pojazdu %q (dozwolone: %v)", req.Type, sortedKeys(vehicleTypeNames))
+	}
+	ft, ok := fuelTypeNames[req.Fuel]
+	if !ok {
+		errs.add("fuel", "nieznany rodzaj paliwa %q (dozwolone: %v)", req.Fuel, sortedKeys(fuelTypeNames))
+	}
+	order := OrderLiters
+	if req.Order != "" {
+		if order, ok = orderModeNames[req.Order]; !ok {
+			errs.add("order", "nieznany tryb zamówienia %q (dozwolone: %v)", req.Order, sortedKeys(orderModeNames))
+		}
+	}
+	if order == OrderLiters && req.FuelAmount <= 0 {
+		errs.add("fuel_amount", "musi być dodatnia, jest %g", req.FuelAmount)
+	}
+	if req.FuelAmount > tankCapacity {
+		errs.add("fuel_amount", "przekracza pojemność zbiornika stacji (%g L), jest %g", tankCapacity, req.FuelAmount)
+	}
+	if req.MoneyLimit < 0 || order == OrderAmount && req.MoneyLimit <= 0 {
+		errs.add("money_limit", "zamówienie za kwotę wymaga dodatniej kwoty, jest %g", req.MoneyLimit)
+	}
+	if req.TankCapacity < 0 || order == OrderFull && req.TankCapacity <= 0 {
+		errs.add("tank_capacity", "zamówienie do pełna wymaga dodatniej pojemności baku, jest %g", req.TankCapacity)
+	} else if req.TankCapacity > tankCapacity {
+		errs.add("tank_capacity", "przekracza pojemność zbiornika stacji (%g L), jest %g", tankCapacity, req.TankCapacity)
+	}
+	if req.TankLevel < 0 || req.TankCapacity > 0 && req.TankLevel > req.TankCapacity {
+		errs.add("tank_level", "musi być w przedziale [0, tank_capacity], jest %g", req.TankLevel)
+	}
+
+	payment := Card
+	if req.Payment != "" {
+		if payment, ok = paymentMethodNames[req.Payment]; !ok {
+			errs.add("payment", "nieznany sposób płatności %q (dozwolone: %v)", req.Payment, sortedKeys(paymentMethodNames))
+		}
+	}
+	tolerance := defaultQueueTolerance[vt]
+	if req.QueueTolerance != nil {
+		if tolerance = *req.QueueTolerance; tolerance < 0 {
+			errs.add("queue_tolerance", "nie może być ujemna, jest %d", tolerance)
+		}
+	}
+	patience := defaultPatience[vt]
+	if req.Patience != "" {
+		patience = parseDuration(&errs, "patience", req.Patience)
+	}
+	priority := defaultPriority[vt]
+	if req.Priority != "" {
+		if priority, ok = priorityNames[req.Priority]; !ok {
+			errs.add("priority", "nieznana klasa priorytetu %q (dozwolone: %v)", req.Priority, sortedKeys(priorityNames))
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return &Vehicle{
+		Type:           vt,
+		FuelType:       ft,
+		FuelAmount:     req.FuelAmount,
+		Order:          order,
+		MoneyLimit:     req.MoneyLimit,
+		TankCapacity:   req.TankCapacity,
+		TankLevel:      req.TankLevel,
+		WaitsForRefill: req.WaitsForRefill,
+		Payment:        payment,
+		Priority:       priority,
+		QueueTolerance: tolerance,
+		Patience:       patience,
+	}, nil
+}
+
+func (gs *GasStation) handleSetPumpOpen(w http.ResponseWriter, r *http.Request, open bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("niepoprawny numer dystrybutora %q", r.PathValue("id")))
+		return
+	}
+	if err := gs.SetPumpOpen(id, open); err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, pumpStatusOf(gs.Pumps[id-1], gs.Clock.Now()))
+}
+
+func (gs *GasStation) handleSetPrices(w http.ResponseWriter, r *http.Request) {
+	var req map[string]float64
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	var errs scenarioErrors
+	prices := make(map[FuelType]float64)
+	for _, name := range sortedKeys(req) {
+		ft, ok := fuelTypeNames[name]
+		if !ok {
+			errs.add(name, "nieznany rodzaj paliwa (dozwolone: %v)", sortedKeys(fuelTypeNames))
+			continue
+		}
+		if req[name] <= 0 {
+			errs.add(name, "cena musi być dodatnia, jest %g", req[name])
+		}
+		prices[ft] = req[name]
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, "niepoprawne ceny", errors.Join(errs...))
+		return
+	}
+
+	gs.SetPrices(prices)
+
+	current := make(map[string]float64)
+	for ft, price := range gs.Pricing.Prices() {
+		current[fuelTypeKey(ft)] = price
+	}
+	writeJSON(w, http.StatusOK, current)
+}
+
+// readJSON dekoduje ciało żądania; przy błędzie wysyła odpowiedź 400
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "niepoprawny JSON: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
+
+// writeValidationError wysyła błędy walidacji, po jednym na pole
+func writeValidationError(w http.ResponseWriter, message string, err error) {
+	var details []string
+	for _, line := range strings.Split(err.Error(), "\n") {
+		details = append(details, strings.TrimSpace(line))
+	}
+	writeJSON(w, http.StatusUnprocessableEntity, errorResponse{Error: message, Details: details})
+}
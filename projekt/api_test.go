@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestAPI zwraca stację wbudowanego scenariusza na zegarze wirtualnym
+// i jej API. Goroutines stacji nie są uruchomione, więc pojazdy dodane
+// przez API czekają w kolejce, a stan stacji zmieniają tylko żądania.
+func newTestAPI() (*GasStation, http.Handler) {
+	gs := NewGasStation(NewVirtualClock(simulationStart), DefaultScenario())
+	gs.Headless = true
+	return gs, NewAPI(gs)
+}
+
+// request wysyła żądanie do handlera i zwraca odpowiedź
+func request(t *testing.T, h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return w
+}
+
+// decode odczytuje odpowiedź JSON do v
+func decode(t *testing.T, w *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("niepoprawny JSON w odpowiedzi: %v\n%s", err, w.Body)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	gs, h := newTestAPI()
+
+	w := request(t, h, "GET", "/status", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /status: kod %d, oczekiwano 200", w.Code)
+	}
+	var status statusResponse
+	decode(t, w, &status)
+	if !status.Running {
+		t.Error("stacja nie jest oznaczona jako działająca")
+	}
+	if len(status.Pumps) != len(gs.Pumps) {
+		t.Errorf("dystrybutory: %d, oczekiwano %d", len(status.Pumps), len(gs.Pumps))
+	}
+	for _, ps := range status.Pumps {
+		if ps.State != "free" {
+			t.Errorf("dystrybutor %d: stan %q, oczekiwano free", ps.ID, ps.State)
+		}
+	}
+	if status.Checkout.Cashiers != 2 || status.Checkout.Busy != 0 {
+		t.Errorf("kasy: %+v, oczekiwano 2 wolnych kas", status.Checkout)
+	}
+	if status.Prices["diesel"] != 6.80 {
+		t.Errorf("cena diesla %v, oczekiwano 6.80", status.Prices["diesel"])
+	}
+}
+
+func TestAddVehicle(t *testing.T) {
+	gs, h := newTestAPI()
+
+	w := request(t, h, "POST", "/vehicles", `{"type": "truck", "fuel": "diesel", "fuel_amount": 150, "payment": "fleet_card"}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("POST /vehicles: kod %d, oczekiwano 202\n%s", w.Code, w.Body)
+	}
+	var resp vehicleResponse
+	decode(t, w, &resp)
+	if resp.ID != 1 || !resp.Queued {
+		t.Errorf("odpowiedź %+v, oczekiwano pojazdu 1 w kolejce", resp)
+	}
+	if n := gs.Queue.LineLen(Diesel); n != 1 {
+		t.Errorf("kolejka diesla: %d, oczekiwano 1", n)
+	}
+}
+
+func TestAddVehicleInvalid(t *testing.T) {
+	tests := []struct {
+		name, body string
+		status     int
+		fields     []string // pola, których dotyczą błędy
+	}{
+		{"niepoprawny JSON", `{"type": `, http.StatusBadRequest, nil},
+		{"nieznane pole", `{"type": "car", "fuel": "lpg", "fuel_amount": 10, "color": "red"}`, http.StatusBadRequest, nil},
+		{"nieznane wartości", `{"type": "bus", "fuel": "petrol", "fuel_amount": 0, "payment": "cheque"}`,
+			http.StatusUnprocessableEntity, []string{"type", "fuel", "fuel_amount", "payment"}},
+		{"niepoprawna cierpliwość", `{"type": "car", "fuel": "lpg", "fuel_amount": 10, "patience": "soon"}`,
+			http.StatusUnprocessableEntity, []string{"patience"}},
+		{"więcej niż zbiornik", `{"type": "truck", "fuel": "diesel", "fuel_amount": 100000, "waits_for_refill": true}`,
+			http.StatusUnprocessableEntity, []string{"fuel_amount"}},
+		{"bak większy od zbiornika", `{"type": "truck", "fuel": "diesel", "order": "full", "tank_capacity": 1000}`,
+			http.StatusUnprocessableEntity, []string{"tank_capacity"}},
+		{"za kwotę bez kwoty", `{"type": "car", "fuel": "diesel", "order": "amount"}`,
+			http.StatusUnprocessableEntity, []string{"money_limit"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs, h := newTestAPI()
+			w := request(t, h, "POST", "/vehicles", tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("kod %d, oczekiwano %d\n%s", w.Code, tt.status, w.Body)
+			}
+			var resp errorResponse
+			decode(t, w, &resp)
+			for _, field := range tt.fields {
+				found := false
+				for _, d := range resp.Details {
+					found = found || strings.HasPrefix(d, field+":")
+				}
+				if !found {
+					t.Errorf("brak błędu pola %s w %q", field, resp.Details)
+				}
+			}
+			if gs.Stats.TotalVehicles != 0 {
+				t.Errorf("odrzucony pojazd trafił na stację (%d pojazdów)", gs.Stats.TotalVehicles)
+			}
+		})
+	}
+}
+
+func TestSetPumpOpen(t *testing.T) {
+	gs, h := newTestAPI()
+
+	w := request(t, h, "POST", "/pumps/3/close", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST /pumps/3/close: kod %d, oczekiwano 200\n%s", w.Code, w.Body)
+	}
+	var ps pumpStatus
+	decode(t, w, &ps)
+	if ps.ID != 3 || ps.State != "closed" {
+		t.Errorf("po zamknięciu: dystrybutor %d w stanie %q, oczekiwano 3 closed", ps.ID, ps.State)
+	}
+	if !gs.Pumps[2].Closed {
+		t.Error("dystrybutor 3 nie jest zamknięty")
+	}
+
+	w = request(t, h, "POST", "/pumps/3/open", "")
+	decode(t, w, &ps)
+	if w.Code != http.StatusOK || ps.State != "free" {
+		t.Errorf("po otwarciu: kod %d, stan %q, oczekiwano 200 free", w.Code, ps.State)
+	}
+	if gs.Pumps[2].Closed {
+		t.Error("dystrybutor 3 nadal jest zamknięty")
+	}
+
+	for _, path := range []string{"/pumps/9/close", "/pumps/0/open"} {
+		if w := request(t, h, "POST", path, ""); w.Code != http.StatusNotFound {
+			t.Errorf("POST %s: kod %d, oczekiwano 404", path, w.Code)
+		}
+	}
+	if w := request(t, h, "POST", "/pumps/x/close", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("POST /pumps/x/close: kod %d, oczekiwano 400", w.Code)
+	}
+}
+
+func TestSetPrices(t *testing.T) {
+	gs, h := newTestAPI()
+
+	w := request(t, h, "PUT", "/prices", `{"diesel": 7.10}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT /prices: kod %d, oczekiwano 200\n%s", w.Code, w.Body)
+	}
+	if price := gs.Pricing.Price(Diesel); price != 7.10 {
+		t.Errorf("cena diesla %v, oczekiwano 7.10", price)
+	}
+	if price := gs.Pricing.Price(LPG); price != 3.50 {
+		t.Errorf("cena LPG zmieniona na %v", price)
+	}
+
+	w = request(t, h, "PUT", "/prices", `{"diesel": -1, "kerosene": 5}`)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("PUT /prices z błędami: kod %d, oczekiwano 422", w.Code)
+	}
+	var resp errorResponse
+	decode(t, w, &resp)
+	if len(resp.Details) != 2 {
+		t.Errorf("błędy %q, oczekiwano dwóch (diesel, kerosene)", resp.Details)
+	}
+	if price := gs.Pricing.Price(Diesel); price != 7.10 {
+		t.Errorf("niepoprawne żądanie zmieniło cenę diesla na %v", price)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Distribution to rozkład odstępów między kolejnymi przyjazdami
+type Distribution struct {
+	Kind     string // "uniform", "exponential" albo "hourly"
+	Min, Max time.Duration
+	Mean     time.Duration
+	// HourlyRates to intensywność przyjazdów (pojazdy na godzinę) w każdej
+	// godzinie doby dla rozkładu "hourly"; MaxRate to jej maksimum
+	HourlyRates [24]float64
+	MaxRate     float64
+}
+
+// Next losuje odstęp od chwili now do kolejnego przyjazdu
+func (d Distribution) Next(rng *rand.Rand, now time.Time) time.Duration {
+	switch d.Kind {
+	case "exponential":
+		return time.Duration(rng.ExpFloat64() * float64(d.Mean))
+	case "hourly":
+		return d.nextHourly(rng, now)
+	default:
+		return d.Min + time.Duration(rng.Int63n(int64(d.Max-d.Min)+1))
+	}
+}
+
+// MeanRate zwraca średnią liczbę przyjazdów na sekundę w oknie
+// od from przez span
+func (d Distribution) MeanRate(from time.Time, span time.Duration) float64 {
+	switch d.Kind {
+	case "exponential":
+		return 1 / d.Mean.Seconds()
+	case "hourly":
+		// Sumujemy oczekiwane przyjazdy w kolejnych (częściach) godzin
+		var arrivals float64
+		end := from.Add(span)
+		for t := from; t.Before(end); {
+			next := t.Add(time.Hour - time.Duration(t.Minute())*time.Minute -
+				time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+			if next.After(end) {
+				next = end
+			}
+			arrivals += d.HourlyRates[t.Hour()] * next.Sub(t).Hours()
+			t = next
+		}
+		return arrivals / span.Seconds()
+	default:
+		return 2 / (d.Min + d.Max).Seconds()
+	}
+}
+
+// nextHourly losuje przyjazd niejednorodnego procesu Poissona metodą
+// przerzedzania (Lewis-Shedler): kandydaci pojawiają się z maksymalną
+// intensywnością, a kandydat w godzinie h jest przyjmowany
+// z prawdopodobieństwem HourlyRates[h] / MaxRate.
+func (d Distribution) nextHourly(rng *rand.Rand, now time.Time) time.Duration {
+	meanGap := float64(time.Hour) / d.MaxRate
+	t := now
+	for {
+		t = t.Add(time.Duration(rng.ExpFloat64() * meanGap))
+		if rng.Float64()*d.MaxRate < d.HourlyRates[t.Hour()] {
+			return t.Sub(now)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// PaymentMethod reprezentuje sposób płatności
+type PaymentMethod int
+
+const (
+	Card PaymentMethod = iota
+	Cash
+	FleetCard
+)
+
+func (pm PaymentMethod) String() string {
+	switch pm {
+	case Card:
+		return "Karta"
+	case Cash:
+		return "Gotówka"
+	case FleetCard:
+		return "Karta flotowa"
+	default:
+		return "Nieznana"
+	}
+}
+
+// Wszystkie sposoby płatności w kolejności wyświetlania
+var allPaymentMethods = []PaymentMethod{Card, Cash, FleetCard}
+
+// Czas obsługi przy kasie dla każdego sposobu płatności
+var paymentTimes = map[PaymentMethod]time.Duration{
+	Card:      2 * time.Second,
+	Cash:      4 * time.Second,
+	FleetCard: 3 * time.Second, // kierowca podaje przebieg i numer rejestracyjny
+}
+
+// paymentShare to udział sposobu płatności wśród kierowców
+type paymentShare struct {
+	method PaymentMethod
+	share  float64
+}
+
+// Sposoby płatności według typu pojazdu; pozostali płacą kartą.
+// Ciężarówki płacą zwykle kartą flotową, pozostali kartą lub gotówką.
+var paymentShares = map[VehicleType][]paymentShare{
+	Car:        {{Cash, 0.3}},
+	Truck:      {{FleetCard, 0.7}},
+	Motorcycle: {{Cash, 0.3}},
+	Emergency:  {{FleetCard, 1}},
+}
+
+// payment to zgłoszenie kierowcy przy kasie: płatność za paliwo albo
+// zakupy w sklepie. Zakupy nie mają czekającego kierowcy - po obsłudze
+// kasjer wywołuje then.
+type payment struct {
+	vehicle   *Vehicle
+	service   time.Duration // czas obsługi przy kasie
+	queuedAt  time.Time
+	queueWait time.Duration
+	done      bool
+	then      func(queueWait time.Duration)
+}
+
+// Checkout reprezentuje kasy stacji obsługiwane przez pulę kasjerów
+type Checkout struct {
+	NumCashiers int
+	clock       Clock
+	queue       []*payment
+	busy        int
+	onDuty      int // kasjerzy na zmianie; pozostali nie przyjmują kierowców
+	closed      bool
+	mutex       sync.Mutex
+	arrived     Cond // kasjerzy czekają na kierowców
+	paid        Cond // kierowcy czekają na koniec płatności
+	wg          sync.WaitGroup
+}
+
+// NewCheckout tworzy kasy z numCashiers kasjerami
+func NewCheckout(clock Clock, numCashiers int) *Checkout {
+	c := &Checkout{
+		NumCashiers: numCashiers,
+		clock:       clock,
+		onDuty:      numCashiers,
+	}
+	c.arrived = clock.NewCond(&c.mutex)
+	c.paid = clock.NewCond(&c.mutex)
+	return c
+}
+
+// Start uruchamia goroutines kasjerów
+func (c *Checkout) Start() {
+	for i := 0; i < c.NumCashiers; i++ {
+		c.wg.Add(1)
+		c.clock.Go(func() { c.runCashier(i) })
+	}
+}
+
+// runCashier obsługuje kolejnych kierowców z kolejki do kas. Kasjer
+// o numerze id (od 0) przyjmuje kierowców tylko wtedy, gdy jest na
+// zmianie (id < onDuty); koniec zmiany nie przerywa trwającej płatności.
+func (c *Checkout) runCashier(id int) {
+	defer c.wg.Done()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for {
+		for (len(c.queue) == 0 || id >= c.onDuty) && !c.closed {
+			c.arrived.Wait()
+		}
+		if len(c.queue) == 0 {
+			return
+		}
+
+		p := c.queue[0]
+		c.queue = c.queue[1:]
+		p.queueWait = c.clock.Since(p.queuedAt)
+		c.busy++
+		c.mutex.Unlock()
+
+		c.clock.Sleep(p.service)
+
+		c.mutex.Lock()
+		c.busy--
+		p.done = true
+		c.paid.Broadcast()
+		if p.then != nil {
+			c.mutex.Unlock()
+			p.then(p.queueWait)
+			c.mutex.Lock()
+		}
+	}
+}
+
+// Pay ustawia kierowcę w kolejce do kas i czeka, aż zapłaci.
+// Zwraca czas oczekiwania na wolną kasę i czas samej płatności.
+func (c *Checkout) Pay(vehicle *Vehicle) (queueWait, serviceTime time.Duration) {
+	p := &payment{
+		vehicle:  vehicle,
+		service:  paymentTimes[vehicle.Payment],
+		queuedAt: c.clock.Now(),
+	}
+
+	c.mutex.Lock()
+	c.queue = append(c.queue, p)
+	c.wake()
+	for !p.done {
+		c.paid.Wait()
+	}
+	c.mutex.Unlock()
+
+	return p.queueWait, c.clock.Since(p.queuedAt) - p.queueWait
+}
+
+// Buy ustawia klienta sklepu w tej samej kolejce do kas co płacących za
+// paliwo i wraca od razu. Po obsłudze (service) kasjer wywołuje then
+// z czasem czekania na wolną kasę. Zwraca długość kolejki do kas.
+func (c *Checkout) Buy(vehicle *Vehicle, service time.Duration, then func(queueWait time.Duration)) int {
+	p := &payment{
+		vehicle:  vehicle,
+		service:  service,
+		queuedAt: c.clock.Now(),
+		then:     then,
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.queue = append(c.queue, p)
+	c.wake()
+	return len(c.queue)
+}
+
+// wake budzi kasjera do nowego kierowcy. Gdy część kasjerów jest poza
+// zmianą, budzi wszystkich, bo Signal mógłby obudzić tylko tego, który
+// kierowcy nie przyjmie. Wywoływana z zablokowanym mutexem kas.
+func (c *Checkout) wake() {
+	if c.onDuty < c.NumCashiers {
+		c.arrived.Broadcast()
+	} else {
+		c.arrived.Signal()
+	}
+}
+
+// SetOnDuty ustawia liczbę kasjerów na zmianie (od 1 do NumCashiers).
+// Kasjer schodzący ze zmiany kończy obsługę bieżącego kierowcy.
+func (c *Checkout) SetOnDuty(n int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.onDuty = min(max(n, 1), c.NumCashiers)
+	c.arrived.Broadcast()
+}
+
+// OnDuty zwraca liczbę kasjerów na zmianie
+func (c *Checkout) OnDuty() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.onDuty
+}
+
+// Status zwraca liczbę zajętych kas i kierowców czekających w kolejce
+func (c *Checkout) Status() (busy, waiting int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.busy, len(c.queue)
+}
+
+// Close zamyka kasy i czeka na kasjerów. Wywoływana po zakończeniu
+// dystrybutorów i wizyt w sklepie, więc nikt już nie czeka na płatność.
+func (c *Checkout) Close() {
+	c.mutex.Lock()
+	c.closed = true
+	c.onDuty = c.NumCashiers // kolejkę do kas kończą wszyscy kasjerzy
+	c.arrived.Broadcast()
+	c.mutex.Unlock()
+
+	c.clock.Await(c.wg.Wait)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckoutOnDutyLimitsPayments(t *testing.T) {
+	// Sześciu kierowców płaci kartą (2 s) jednocześnie w trzech kasach
+	tests := []struct {
+		onDuty int
+		last   time.Duration // koniec ostatniej płatności
+	}{
+		{1, 12 * time.Second},
+		{2, 6 * time.Second},
+		{3, 4 * time.Second},
+	}
+	for _, tt := range tests {
+		clock := NewVirtualClock(simulationStart)
+		c := NewCheckout(clock, 3)
+		c.SetOnDuty(tt.onDuty)
+		c.Start()
+
+		var last time.Time
+		for range 6 {
+			clock.Go(func() {
+				c.Pay(&Vehicle{Payment: Card})
+				if now := clock.Now(); now.After(last) {
+					last = now
+				}
+			})
+		}
+		busiest := 0
+		for range 60 {
+			clock.Sleep(250 * time.Millisecond)
+			busy, _ := c.Status()
+			busiest = max(busiest, busy)
+		}
+		c.Close()
+
+		if busiest != tt.onDuty {
+			t.Errorf("na zmianie %d: najwięcej jednoczesnych płatności %d", tt.onDuty, busiest)
+		}
+		if got := last.Sub(simulationStart); got != tt.last {
+			t.Errorf("na zmianie %d: ostatnia płatność skończyła się po %v, oczekiwano %v", tt.onDuty, got, tt.last)
+		}
+	}
+}
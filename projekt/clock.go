@@ -0,0 +1,412 @@
+package main
+
+import (
+	"container/heap"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Clock jest źródłem czasu symulacji. Goroutines stacji uruchamiane są
+// przez Go, a czekają wyłącznie przez Sleep i zmienne warunkowe z NewCond,
+// dzięki czemu ta sama symulacja działa w czasie rzeczywistym (RealClock,
+// ScaledClock) albo wirtualnym (VirtualClock).
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	Sleep(d time.Duration)
+	// Go uruchamia goroutine symulacji
+	Go(f func())
+	// AfterFunc uruchamia f jako goroutine symulacji po upływie d
+	AfterFunc(d time.Duration, f func()) Timer
+	// NewCond tworzy zmienną warunkową współpracującą z zegarem
+	NewCond(l sync.Locker) Cond
+	// Await wywołuje blokującą funkcję spoza zegara (np. WaitGroup.Wait),
+	// pozwalając w tym czasie działać goroutines symulacji
+	Await(wait func())
+}
+
+// Timer to odliczanie z AfterFunc. Stop zwraca false, jeśli funkcja
+// została już uruchomiona albo odliczanie wcześniej zatrzymano.
+type Timer interface {
+	Stop() bool
+}
+
+// Cond to zmienna warunkowa o semantyce sync.Cond
+type Cond interface {
+	Wait()
+	Signal()
+	Broadcast()
+}
+
+// RealClock to zegar ścienny - symulacja trwa tyle, ile wynika z jej parametrów
+type RealClock struct{}
+
+func (RealClock) Now() time.Time                  { return time.Now() }
+func (RealClock) Since(t time.Time) time.Duration { return time.Since(t) }
+func (RealClock) Sleep(d time.Duration)           { time.Sleep(d) }
+func (RealClock) Go(f func())                     { go f() }
+func (RealClock) NewCond(l sync.Locker) Cond      { return sync.NewCond(l) }
+func (RealClock) Await(wait func())               { wait() }
+
+func (RealClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// ScaledClock to zegar ścienny, którego bieg można wstrzymać
+// i przyspieszyć: czas symulacji płynie speed razy szybciej niż
+// rzeczywisty. Steruje nim interfejs terminalowy.
+type ScaledClock struct {
+	origin  time.Time // czas symulacji w chwili anchor
+	anchor  time.Time // czas ścienny ostatniej zmiany biegu
+	speed   float64
+	paused  bool
+	changed chan struct{} // zamykany przy każdej zmianie biegu
+	mutex   sync.Mutex
+}
+
+// NewScaledClock tworzy zegar biegnący speed razy szybciej niż ścienny,
+// wskazujący na początku bieżący czas
+func NewScaledClock(speed float64) *ScaledClock {
+	now := time.Now()
+	return &ScaledClock{origin: now, anchor: now, speed: speed, changed: make(chan struct{})}
+}
+
+// Now zwraca bieżący czas symulacji
+func (c *ScaledClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now()
+}
+
+// now wylicza czas symulacji. Wywoływana z zablokowanym mutexem.
+func (c *ScaledClock) now() time.Time {
+	if c.paused {
+		return c.origin
+	}
+	return c.origin.Add(time.Duration(float64(time.Since(c.anchor)) * c.speed))
+}
+
+// Since zwraca czas symulacji, jaki upłynął od t
+func (c *ScaledClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Speed zwraca tempo zegara i to, czy jest wstrzymany
+func (c *ScaledClock) Speed() (speed float64, paused bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.speed, c.paused
+}
+
+// SetSpeed zmienia tempo upływu czasu symulacji
+func (c *ScaledClock) SetSpeed(speed float64) {
+	c.change(func() { c.speed = speed })
+}
+
+// SetPaused wstrzymuje albo wznawia upływ czasu symulacji
+func (c *ScaledClock) SetPaused(paused bool) {
+	c.change(func() { c.paused = paused })
+}
+
+// change zmienia bieg zegara, licząc dalszy czas od bieżącej chwili,
+// i budzi śpiące goroutines, żeby przeliczyły czas czekania
+func (c *ScaledClock) change(f func()) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.origin = c.now()
+	c.anchor = time.Now()
+	f()
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// Sleep usypia goroutine na d czasu symulacji
+func (c *ScaledClock) Sleep(d time.Duration) {
+	c.sleepUntil(c.Now().Add(d), nil)
+}
+
+// sleepUntil czeka, aż czas symulacji dojdzie do t. Zwraca false, jeśli
+// wcześniej zamknięto cancel.
+func (c *ScaledClock) sleepUntil(t time.Time, cancel <-chan struct{}) bool {
+	for {
+		c.mutex.Lock()
+		left := t.Sub(c.now())
+		speed, paused, changed := c.speed, c.paused, c.changed
+		c.mutex.Unlock()
+		if left <= 0 {
+			return true
+		}
+
+		// Wstrzymany zegar czeka tylko na zmianę biegu
+		var timer *time.Timer
+		var elapsed <-chan time.Time
+		if !paused {
+			timer = time.NewTimer(time.Duration(float64(left) / speed))
+			elapsed = timer.C
+		}
+		select {
+		case <-elapsed:
+		case <-changed:
+		case <-cancel:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		select {
+		case <-cancel:
+			return false
+		default:
+		}
+	}
+}
+
+func (c *ScaledClock) Go(f func())                { go f() }
+func (c *ScaledClock) NewCond(l sync.Locker) Cond { return sync.NewCond(l) }
+func (c *ScaledClock) Await(wait func())          { wait() }
+
+// AfterFunc uruchamia f po upływie d czasu symulacji
+func (c *ScaledClock) AfterFunc(d time.Duration, f func()) Timer {
+	t := &scaledTimer{stop: make(chan struct{})}
+	at := c.Now().Add(d)
+	go func() {
+		if c.sleepUntil(at, t.stop) && t.done.CompareAndSwap(false, true) {
+			f()
+		}
+	}()
+	return t
+}
+
+// scaledTimer to odliczanie zegara ScaledClock
+type scaledTimer struct {
+	stop chan struct{}
+	done atomic.Bool // funkcja uruchomiona albo odliczanie zatrzymane
+}
+
+// Stop przerywa odliczanie, jeśli funkcja jeszcze nie została uruchomiona
+func (t *scaledTimer) Stop() bool {
+	if !t.done.CompareAndSwap(false, true) {
+		return false
+	}
+	close(t.stop)
+	return true
+}
+
+// VirtualClock to zegar symulacji dyskretnej. W danej chwili działa tylko
+// jedna goroutine symulacji; gdy zaśnie lub zaczeka na zmienną warunkową,
+// zegar przeskakuje do najbliższego zdarzenia i budzi jego właściciela.
+// Zdarzenia z tym samym czasem są obsługiwane w kolejności zgłoszenia, więc
+// przy tym samym ziarnie losowania przebieg symulacji jest zawsze taki sam.
+type VirtualClock struct {
+	now    time.Time
+	seq    uint64
+	events eventQueue
+	busy   bool // któraś goroutine symulacji właśnie działa
+	mutex  sync.Mutex
+	idle   *sync.Cond
+}
+
+// event to obudzenie czekającej goroutine (wake) albo start nowej (run)
+type event struct {
+	at        time.Time
+	seq       uint64
+	wake      chan struct{}
+	run       func()
+	fired     bool
+	cancelled bool
+	index     int // pozycja w kopcu (-1 - zdjęte z kopca)
+}
+
+// NewVirtualClock tworzy zegar wirtualny wskazujący czas start
+func NewVirtualClock(start time.Time) *VirtualClock {
+	c := &VirtualClock{now: start}
+	c.idle = sync.NewCond(&c.mutex)
+	return c
+}
+
+// Now zwraca bieżący czas wirtualny
+func (c *VirtualClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+// Since zwraca czas wirtualny, jaki upłynął od t
+func (c *VirtualClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Sleep usypia goroutine na d czasu wirtualnego. Wywołana spoza symulacji
+// (np. z main) uruchamia ją i wraca po upływie d.
+func (c *VirtualClock) Sleep(d time.Duration) {
+	c.mutex.Lock()
+	c.busy = true
+	wake := make(chan struct{})
+	c.schedule(&event{at: c.now.Add(d), wake: wake})
+	c.dispatch()
+	c.mutex.Unlock()
+	<-wake
+}
+
+// Go planuje start goroutine symulacji na bieżącą chwilę
+func (c *VirtualClock) Go(f func()) {
+	c.mutex.Lock()
+	c.schedule(&event{at: c.now, run: f})
+	c.mutex.Unlock()
+}
+
+// AfterFunc planuje start goroutine symulacji po upływie d
+func (c *VirtualClock) AfterFunc(d time.Duration, f func()) Timer {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	ev := &event{at: c.now.Add(d), run: f}
+	c.schedule(ev)
+	return &virtualTimer{clock: c, ev: ev}
+}
+
+// virtualTimer to odliczanie zegara wirtualnego
+type virtualTimer struct {
+	clock *VirtualClock
+	ev    *event
+}
+
+// Stop odwołuje zdarzenie i od razu zdejmuje je z kopca - odwołane
+// odliczania cierpliwości nie zalegają w nim do swojego terminu
+func (t *virtualTimer) Stop() bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+
+	if t.ev.fired || t.ev.cancelled {
+		return false
+	}
+	t.ev.cancelled = true
+	heap.Remove(&t.clock.events, t.ev.index)
+	return true
+}
+
+// NewCond tworzy zmienną warunkową, której Wait oddaje sterowanie zegarowi
+func (c *VirtualClock) NewCond(l sync.Locker) Cond {
+	return &virtualCond{clock: c, l: l}
+}
+
+// Await oddaje sterowanie symulacji na czas wait i odzyskuje je, gdy
+// wszystkie zaplanowane zdarzenia zostaną obsłużone
+func (c *VirtualClock) Await(wait func()) {
+	c.mutex.Lock()
+	c.dispatch()
+	c.mutex.Unlock()
+
+	wait()
+
+	c.mutex.Lock()
+	for c.busy {
+		c.idle.Wait()
+	}
+	c.busy = true
+	c.mutex.Unlock()
+}
+
+// schedule dodaje zdarzenie do kolejki. Wywoływana z zablokowanym mutexem.
+func (c *VirtualClock) schedule(ev *event) {
+	ev.seq = c.seq
+	c.seq++
+	heap.Push(&c.events, ev)
+}
+
+// dispatch przekazuje sterowanie do najbliższego zdarzenia, przesuwając
+// czas wirtualny. Wywoływana z zablokowanym mutexem przez goroutine,
+// która właśnie przestaje działać.
+func (c *VirtualClock) dispatch() {
+	if c.events.Len() == 0 {
+		c.busy = false
+		c.idle.Broadcast()
+		return
+	}
+
+	ev := heap.Pop(&c.events).(*event)
+	ev.fired = true
+	if ev.at.After(c.now) {
+		c.now = ev.at
+	}
+	if ev.run != nil {
+		go func() {
+			ev.run()
+			c.mutex.Lock()
+			c.dispatch()
+			c.mutex.Unlock()
+		}()
+	} else {
+		close(ev.wake)
+	}
+}
+
+// virtualCond to zmienna warunkowa dla VirtualClock
+type virtualCond struct {
+	clock   *VirtualClock
+	l       sync.Locker
+	waiters []chan struct{} // chronione mutexem zegara
+}
+
+func (vc *virtualCond) Wait() {
+	c := vc.clock
+	wake := make(chan struct{})
+
+	c.mutex.Lock()
+	vc.waiters = append(vc.waiters, wake)
+	c.dispatch()
+	c.mutex.Unlock()
+
+	vc.l.Unlock()
+	<-wake
+	vc.l.Lock()
+}
+
+func (vc *virtualCond) Signal() {
+	c := vc.clock
+	c.mutex.Lock()
+	if len(vc.waiters) > 0 {
+		c.schedule(&event{at: c.now, wake: vc.waiters[0]})
+		vc.waiters = vc.waiters[1:]
+	}
+	c.mutex.Unlock()
+}
+
+func (vc *virtualCond) Broadcast() {
+	c := vc.clock
+	c.mutex.Lock()
+	for _, wake := range vc.waiters {
+		c.schedule(&event{at: c.now, wake: wake})
+	}
+	vc.waiters = nil
+	c.mutex.Unlock()
+}
+
+// eventQueue to kopiec zdarzeń uporządkowany po czasie i kolejności zgłoszenia
+type eventQueue []*event
+
+func (q eventQueue) Len() int { return len(q) }
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].at.Equal(q[j].at) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].at.Before(q[j].at)
+}
+func (q eventQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index, q[j].index = i, j
+}
+func (q *eventQueue) Push(x any) {
+	ev := x.(*event)
+	ev.index = len(*q)
+	*q = append(*q, ev)
+}
+func (q *eventQueue) Pop() any {
+	old := *q
+	ev := old[len(old)-1]
+	old[len(old)-1] = nil
+	ev.index = -1
+	*q = old[:len(old)-1]
+	return ev
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Dispatcher przydziela pojazdy do dystrybutorów. Każdy rodzaj paliwa ma
+// osobną kolejkę, więc pojazd czekający na LPG nie blokuje kierowców
+// tankujących benzynę. Dystrybutor dostaje tylko pojazd z paliwem,
+// które sprzedaje, i tylko taki, który się przy nim mieści. Pojazdy
+// wyższych klas priorytetu wyprzedzają pozostałe, a czekanie podnosi
+// priorytet (aging), więc nikt nie czeka w nieskończoność.
+type Dispatcher struct {
+	lines    map[FuelType][]*Vehicle // w kolejności przyjazdu
+	served   map[service]bool
+	offline  map[*Pump]bool // dystrybutory wyłączone z obsługi
+	capacity int
+	size     int
+	aging    time.Duration // czas czekania podnoszący priorytet o poziom
+	closed   bool
+	clock    Clock
+	mutex    sync.Mutex
+	cond     Cond
+}
+
+// NewDispatcher tworzy dyspozytora dla podanych dystrybutorów.
+// capacity ogranicza łączną liczbę pojazdów we wszystkich kolejkach,
+// aging to czas czekania, po którym pojazd awansuje o poziom priorytetu.
+func NewDispatcher(clock Clock, pumps []*Pump, capacity int, aging time.Duration) *Dispatcher {
+	d := &Dispatcher{
+		lines:    make(map[FuelType][]*Vehicle),
+		served:   make(map[service]bool),
+		offline:  make(map[*Pump]bool),
+		capacity: capacity,
+		aging:    aging,
+		clock:    clock,
+	}
+	d.cond = clock.NewCond(&d.mutex)
+
+	for _, pump := range pumps {
+		for _, ft := range pump.FuelTypes {
+			for _, vt := range pump.Vehicles {
+				d.served[service{ft, vt}] = true
+			}
+		}
+	}
+	return d
+}
+
+// service to para paliwo - typ pojazdu, którą obsługuje dystrybutor
+type service struct {
+	fuel    FuelType
+	vehicle VehicleType
+}
+
+// Serves sprawdza, czy którykolwiek dystrybutor sprzedaje paliwo pojazdu
+// i mieści pojazd tego typu
+func (d *Dispatcher) Serves(vehicle *Vehicle) bool {
+	return d.served[service{vehicle.FuelType, vehicle.Type}]
+}
+
+// Add ustawia pojazd w kolejce jego paliwa, o ile kierowca zechce do niej
+// dołączyć: kolejki nie są pełne, a liczba czekających pojazdów nie
+// przekracza tolerance. Zwraca false, gdy pojazd nie dołączył (także po
+// zamknięciu dyspozytora).
+func (d *Dispatcher) Add(vehicle *Vehicle, tolerance int) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.closed || d.size >= d.capacity || d.size > tolerance {
+		return false
+	}
+
+	d.lines[vehicle.FuelType] = append(d.lines[vehicle.FuelType], vehicle)
+	d.size++
+	// Budzimy wszystkich - czekające dystrybutory sprzedają różne paliwa
+	d.cond.Broadcast()
+	return true
+}
+
+// Next czeka na pojazd, który może zatankować na danym dystrybutorze.
+// Spośród mieszczących się przy nim pojazdów w kolejkach paliw
+// sprzedawanych przez dystrybutor wybiera ten z najwyższym bieżącym
+// priorytetem (patrz ahead), a przy równym - ten, który przyjechał
+// najwcześniej. Wyłączony dystrybutor czeka, aż zostanie ponownie
+// otwarty. Po zamknięciu dyspozytora wydaje pojazdy, które zostały
+// w kolejkach, a gdy ich zabraknie, zwraca nil.
+func (d *Dispatcher) Next(pump *Pump) *Vehicle {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for {
+		now := d.clock.Now()
+		var best FuelType
+		var index int
+		var vehicle *Vehicle
+		if !d.offline[pump] {
+			for _, ft := range pump.FuelTypes {
+				for i, v := range d.lines[ft] {
+					if !pump.Fits(v.Type) {
+						continue
+					}
+					if vehicle == nil || ahead(v, vehicle, now, d.aging) {
+						best, index, vehicle = ft, i, v
+					}
+				}
+			}
+		}
+
+		if vehicle != nil {
+			line := d.lines[best]
+			d.lines[best] = append(line[:index], line[index+1:]...)
+			d.size--
+			return vehicle
+		}
+		if d.closed {
+			return nil
+		}
+		d.cond.Wait()
+	}
+}
+
+// Requeue ustawia pojazd na początku kolejki jego paliwa, niezależnie od
+// jej długości. Służy pojazdom, którym awaria dystrybutora przerwała
+// tankowanie (Next wydaje je przed innymi) - po zamknięciu dyspozytora
+// czekają na obsługę jak pozostałe.
+func (d *Dispatcher) Requeue(vehicle *Vehicle) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.lines[vehicle.FuelType] = append([]*Vehicle{vehicle}, d.lines[vehicle.FuelType]...)
+	d.size++
+	d.cond.Broadcast()
+}
+
+// SetPumpOnline włącza dystrybutor do obsługi albo go z niej wyłącza.
+// Wyłączony dystrybutor kończy obsługę bieżącego pojazdu, ale nie dostaje
+// kolejnych.
+func (d *Dispatcher) SetPumpOnline(pump *Pump, online bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if online {
+		delete(d.offline, pump)
+		d.cond.Broadcast()
+	} else {
+		d.offline[pump] = true
+	}
+}
+
+// Remove usuwa z kolejki pojazd, którego kierowca stracił cierpliwość.
+// Zwraca false, jeśli pojazd już z niej wyjechał.
+func (d *Dispatcher) Remove(vehicle *Vehicle) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	line := d.lines[vehicle.FuelType]
+	for i, v := range line {
+		if v == vehicle {
+			d.lines[vehicle.FuelType] = append(line[:i], line[i+1:]...)
+			d.size--
+			return true
+		}
+	}
+	return false
+}
+
+// Len zwraca łączną liczbę pojazdów w kolejkach
+func (d *Dispatcher) Len() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.size
+}
+
+// LineLen zwraca liczbę pojazdów w kolejce danego paliwa
+func (d *Dispatcher) LineLen(fuelType FuelType) int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return len(d.lines[fuelType])
+}
+
+// Waiting zwraca typy pojazdów czekających w kolejce danego paliwa,
+// w kolejności przyjazdu
+func (d *Dispatcher) Waiting(fuelType FuelType) []VehicleType {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	types := make([]VehicleType, len(d.lines[fuelType]))
+	for i, vehicle := range d.lines[fuelType] {
+		types[i] = vehicle.Type
+	}
+	return types
+}
+
+// OldestArrival zwraca czas przyjazdu najdłużej czekającego pojazdu klasy
+// priority we wszystkich kolejkach; false, gdy żaden taki nie czeka
+func (d *Dispatcher) OldestArrival(priority Priority) (time.Time, bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var oldest time.Time
+	found := false
+	for _, line := range d.lines {
+		for _, vehicle := range line {
+			if vehicle.Priority == priority && (!found || vehicle.ArrivalTime.Before(oldest)) {
+				oldest, found = vehicle.ArrivalTime, true
+			}
+		}
+	}
+	return oldest, found
+}
+
+// Close zamyka kolejki dla nowych pojazdów i budzi wszystkie czekające
+// goroutines
+func (d *Dispatcher) Close() {
+	d.mutex.Lock()
+	d.closed = true
+	d.cond.Broadcast()
+	d.mutex.Unlock()
+}
+
+// Clear usuwa z kolejek wszystkie czekające pojazdy i je zwraca
+func (d *Dispatcher) Clear() []*Vehicle {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var waiting []*Vehicle
+	for _, ft := range allFuelTypes {
+		waiting = append(waiting, d.lines[ft]...)
+		d.lines[ft] = nil
+	}
+	d.size = 0
+	return waiting
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// newTestDispatcher tworzy dyspozytora dla dwóch dystrybutorów:
+// benzyny 95 i diesla dla samochodów i motocykli oraz pasa diesla dla
+// ciężarówek
+func newTestDispatcher() (*Dispatcher, *Pump, *Pump) {
+	clock := NewVirtualClock(simulationStart)
+	cars := &Pump{ID: 1, FuelTypes: []FuelType{Gasoline95, Diesel}, Vehicles: []VehicleType{Car, Motorcycle}}
+	trucks := &Pump{ID: 2, FuelTypes: []FuelType{Diesel}, Vehicles: []VehicleType{Truck}}
+	return NewDispatcher(clock, []*Pump{cars, trucks}, 10, time.Minute), cars, trucks
+}
+
+// queue ustawia w kolejkach dyspozytora pojazdy przybyłe co sekundę
+func queue(t *testing.T, d *Dispatcher, vehicles ...*Vehicle) {
+	t.Helper()
+	for i, v := range vehicles {
+		v.ID = i + 1
+		v.ArrivalTime = simulationStart.Add(time.Duration(i) * time.Second)
+		if !d.Add(v, 10) {
+			t.Fatalf("pojazd %d nie dołączył do kolejki", v.ID)
+		}
+	}
+}
+
+func TestServes(t *testing.T) {
+	d, _, _ := newTestDispatcher()
+	tests := []struct {
+		vehicle VehicleType
+		fuel    FuelType
+		want    bool
+	}{
+		{Car, Gasoline95, true},
+		{Car, Diesel, true},
+		{Motorcycle, Diesel, true},
+		{Truck, Diesel, true},
+		{Truck, Gasoline95, false}, // benzyna tylko na dystrybutorze dla samochodów
+		{Car, LPG, false},
+		{Emergency, Diesel, false},
+	}
+	for _, tt := range tests {
+		if got := d.Serves(&Vehicle{Type: tt.vehicle, FuelType: tt.fuel}); got != tt.want {
+			t.Errorf("Serves(%v, %v) = %v, oczekiwano %v", tt.vehicle, tt.fuel, got, tt.want)
+		}
+	}
+}
+
+func TestNextMatchesFuelAndVehicleType(t *testing.T) {
+	d, cars, trucks := newTestDispatcher()
+	queue(t, d,
+		&Vehicle{Type: Truck, FuelType: Diesel},
+		&Vehicle{Type: Car, FuelType: Diesel},
+		&Vehicle{Type: Car, FuelType: Gasoline95},
+		&Vehicle{Type: Motorcycle, FuelType: Diesel},
+	)
+	// Po zamknięciu Next wydaje pozostałe pojazdy i zwraca nil zamiast czekać
+	d.Close()
+
+	tests := []struct {
+		pump *Pump
+		want []int // numery pojazdów w kolejności wydania
+	}{
+		{trucks, []int{1}},
+		{cars, []int{2, 3, 4}},
+	}
+	for _, tt := range tests {
+		var got []int
+		for v := d.Next(tt.pump); v != nil; v = d.Next(tt.pump) {
+			got = append(got, v.ID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("dystrybutor %d wydał pojazdy %v, oczekiwano %v", tt.pump.ID, got, tt.want)
+		}
+	}
+	if d.Len() != 0 {
+		t.Errorf("w kolejkach zostało %d pojazdów", d.Len())
+	}
+}
+
+func TestNextSkipsOfflinePump(t *testing.T) {
+	d, cars, _ := newTestDispatcher()
+	queue(t, d, &Vehicle{Type: Car, FuelType: Diesel})
+	d.SetPumpOnline(cars, false)
+	d.Close()
+
+	if v := d.Next(cars); v != nil {
+		t.Fatalf("wyłączony dystrybutor dostał pojazd %d", v.ID)
+	}
+	d.SetPumpOnline(cars, true)
+	if v := d.Next(cars); v == nil || v.ID != 1 {
+		t.Errorf("po włączeniu dystrybutor dostał %v, oczekiwano pojazdu 1", v)
+	}
+}
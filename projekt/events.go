@@ -0,0 +1,158 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Rodzaje zdarzeń publikowanych przez stację
+const (
+	EventArrived           = "arrived"            // pojazd przyjechał na stację
+	EventRefuelStarted     = "refuel_started"     // pojazd podjechał do dystrybutora
+	EventRefuelInterrupted = "refuel_interrupted" // awaria dystrybutora, pojazd wraca do kolejki
+	EventRefuelFinished    = "refuel_finished"    // koniec tankowania, kierowca idzie do kasy
+	EventPaid              = "paid"               // kierowca zapłacił i zwolnił dystrybutor
+	EventLeft              = "left"               // pojazd odjechał bez obsługi (Reason)
+	EventPriceChanged      = "price_changed"      // nowa cena paliwa (Price)
+	EventShopPaid          = "shop_paid"          // kierowca zapłacił za zakupy w sklepie
+	EventWashed            = "washed"             // koniec mycia pojazdu
+)
+
+// Powody odjazdu bez obsługi (Event.Reason dla EventLeft)
+const (
+	LeftNoPump     = "no_pump"     // żaden dystrybutor nie sprzedaje paliwa
+	LeftBalked     = "balked"      // zbyt długa kolejka
+	LeftReneged    = "reneged"     // koniec cierpliwości w kolejce
+	LeftStockOut   = "stock_out"   // brak paliwa w zbiorniku
+	LeftTurnedAway = "turned_away" // odesłany przy zatrzymaniu stacji
+	LeftPrice      = "price"       // cena powyżej limitu kierowcy
+	LeftClosed     = "closed"      // stacja poza godzinami otwarcia
+)
+
+// Powody zakończenia tankowania przed wydaniem całego zamówionego paliwa
+// (Event.Reason dla EventRefuelFinished)
+const (
+	StoppedMoneyLimit = "money_limit" // kwota doszła do limitu kierowcy
+	StoppedShutdown   = "shutdown"    // zatrzymanie stacji w trybie abort
+)
+
+// Event to pojedyncza zmiana stanu stacji
+type Event struct {
+	Seq         uint64    `json:"seq"`
+	Time        time.Time `json:"time"`
+	Kind        string    `json:"kind"`
+	VehicleID   int       `json:"vehicle_id,omitempty"`
+	VehicleType string    `json:"vehicle_type,omitempty"`
+	Fuel        string    `json:"fuel"`
+	Pump        int       `json:"pump,omitempty"`
+	Amount      float64   `json:"amount,omitempty"` // litry
+	Cost        float64   `json:"cost,omitempty"`
+	Price       float64   `json:"price,omitempty"` // PLN za litr
+	Payment     string    `json:"payment,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+	Shift       string    `json:"shift,omitempty"` // nazwa zmiany obsługi
+}
+
+// EventBus rozsyła zdarzenia do subskrybentów. Publish nigdy nie czeka:
+// gdy bufor subskrybenta jest pełny, zdarzenie jest pomijane, a
+// subskrybent dostaje liczbę pominiętych zdarzeń.
+type EventBus struct {
+	clock  Clock
+	seq    uint64
+	subs   map[*Subscription]struct{}
+	closed bool
+	mutex  sync.Mutex
+}
+
+// Subscription to subskrypcja zdarzeń. C jest zamykany po Unsubscribe
+// albo zamknięciu magistrali.
+type Subscription struct {
+	C       chan Event
+	dropped uint64 // chronione mutexem magistrali
+	bus     *EventBus
+}
+
+// NewEventBus tworzy magistralę zdarzeń z czasem według zegara clock
+func NewEventBus(clock Clock) *EventBus {
+	return &EventBus{
+		clock: clock,
+		subs:  make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe zapisuje nowego subskrybenta z buforem na buffer zdarzeń
+func (b *EventBus) Subscribe(buffer int) *Subscription {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	sub := &Subscription{C: make(chan Event, buffer), bus: b}
+	if b.closed {
+		close(sub.C)
+	} else {
+		b.subs[sub] = struct{}{}
+	}
+	return sub
+}
+
+// Unsubscribe wypisuje subskrybenta i zamyka jego kanał
+func (b *EventBus) Unsubscribe(sub *Subscription) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.C)
+	}
+}
+
+// Publish nadaje zdarzeniu numer i czas, po czym wysyła je do wszystkich
+// subskrybentów, nie czekając na żadnego z nich
+func (b *EventBus) Publish(e Event) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.seq++
+	e.Seq = b.seq
+	e.Time = b.clock.Now()
+	for sub := range b.subs {
+		select {
+		case sub.C <- e:
+		default:
+			sub.dropped++
+		}
+	}
+}
+
+// Close zamyka kanały wszystkich subskrybentów
+func (b *EventBus) Close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.closed = true
+	for sub := range b.subs {
+		close(sub.C)
+	}
+	b.subs = nil
+}
+
+// TakeDropped zwraca liczbę zdarzeń pominiętych od poprzedniego wywołania
+func (s *Subscription) TakeDropped() uint64 {
+	s.bus.mutex.Lock()
+	defer s.bus.mutex.Unlock()
+
+	n := s.dropped
+	s.dropped = 0
+	return n
+}
+
+// publish wysyła zdarzenie dotyczące pojazdu
+func (gs *GasStation) publish(kind string, vehicle *Vehicle, e Event) {
+	e.Kind = kind
+	e.VehicleID = vehicle.ID
+	e.VehicleType = nameOf(vehicleTypeNames, vehicle.Type)
+	e.Fuel = fuelTypeKey(vehicle.FuelType)
+	gs.Events.Publish(e)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestEventBusDropsForSlowSubscriber(t *testing.T) {
+	bus := NewEventBus(NewVirtualClock(simulationStart))
+	slow := bus.Subscribe(2) // nie odbiera zdarzeń
+	fast := bus.Subscribe(10)
+
+	// Publish nie może czekać na pełny bufor wolnego subskrybenta
+	done := make(chan struct{})
+	go func() {
+		for range 5 {
+			bus.Publish(Event{Kind: EventArrived})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish czeka na wolnego subskrybenta")
+	}
+
+	if n := slow.TakeDropped(); n != 3 {
+		t.Errorf("wolny subskrybent: pominięto %d zdarzeń, oczekiwano 3", n)
+	}
+	if n := slow.TakeDropped(); n != 0 {
+		t.Errorf("TakeDropped po odczycie zwraca %d, oczekiwano 0", n)
+	}
+	if n := fast.TakeDropped(); n != 0 {
+		t.Errorf("szybki subskrybent: pominięto %d zdarzeń", n)
+	}
+
+	bus.Close()
+	for _, tt := range []struct {
+		name string
+		sub  *Subscription
+		want []uint64
+	}{
+		{"wolny", slow, []uint64{1, 2}},
+		{"szybki", fast, []uint64{1, 2, 3, 4, 5}},
+	} {
+		var got []uint64
+		for e := range tt.sub.C {
+			got = append(got, e.Seq)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s subskrybent dostał zdarzenia %v, oczekiwano %v", tt.name, got, tt.want)
+		}
+	}
+}
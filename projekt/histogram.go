@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"math/bits"
+	"sync"
+	"time"
+)
+
+// Rozdzielczość histogramu: każdy przedział potęgi dwójki dzieli się na
+// histogramSubBuckets równych części, więc błąd percentyla nie przekracza
+// ok. 6%. Wartości zapisywane są w milisekundach.
+const (
+	histogramSubBits    = 4
+	histogramSubBuckets = 1 << histogramSubBits
+	histogramUnit       = time.Millisecond
+)
+
+// Histogram zlicza czasy w przedziałach o stałej względnej szerokości
+// (w stylu HDR), co pozwala odczytać percentyle bez przechowywania
+// wszystkich pomiarów. Można go używać z wielu goroutines.
+type Histogram struct {
+	counts []int64
+	count  int64
+	sum    time.Duration
+	max    time.Duration
+	mutex  sync.Mutex
+}
+
+// Record dopisuje pomiar do histogramu
+func (h *Histogram) Record(d time.Duration) {
+	i := bucketIndex(d)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if i >= len(h.counts) {
+		h.counts = append(h.counts, make([]int64, i+1-len(h.counts))...)
+	}
+	h.counts[i]++
+	h.count++
+	h.sum += d
+	h.max = max(h.max, d)
+}
+
+// Count zwraca liczbę pomiarów
+func (h *Histogram) Count() int64 {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.count
+}
+
+// Sum zwraca sumę wszystkich pomiarów
+func (h *Histogram) Sum() time.Duration {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.sum
+}
+
+// CountAtMost zwraca liczbę pomiarów nie większych niż d. Liczone są
+// całe przedziały histogramu, więc wynik może być zaniżony o pomiary
+// z przedziału, w którym leży d.
+func (h *Histogram) CountAtMost(d time.Duration) int64 {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	var n int64
+	for i, c := range h.counts {
+		if bucketUpperBound(i) > d {
+			break
+		}
+		n += c
+	}
+	return n
+}
+
+// Max zwraca najdłuższy zapisany czas
+func (h *Histogram) Max() time.Duration {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.max
+}
+
+// Quantile zwraca czas, którego nie przekracza ułamek q pomiarów
+// (np. 0.99 dla p99). Wynik to górna granica przedziału, nie większa
+// niż maksimum.
+func (h *Histogram) Quantile(q float64) time.Duration {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.count == 0 {
+		return 0
+	}
+	rank := int64(q*float64(h.count) + 0.5)
+	rank = min(max(rank, 1), h.count)
+
+	var seen int64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= rank {
+			return min(bucketUpperBound(i), h.max)
+		}
+	}
+	return h.max
+}
+
+// bucketIndex zwraca numer przedziału dla czasu d
+func bucketIndex(d time.Duration) int {
+	v := uint64(max(d, 0) / histogramUnit)
+	if v < 2*histogramSubBuckets {
+		return int(v)
+	}
+	shift := bits.Len64(v) - histogramSubBits - 1
+	return histogramSubBuckets*shift + int(v>>shift)
+}
+
+// bucketUpperBound zwraca największy czas należący do przedziału i
+func bucketUpperBound(i int) time.Duration {
+	if i < 2*histogramSubBuckets {
+		return time.Duration(i+1)*histogramUnit - 1
+	}
+	shift := i/histogramSubBuckets - 1
+	top := uint64(i%histogramSubBuckets + histogramSubBuckets)
+	return time.Duration((top+1)<<shift)*histogramUnit - 1
+}
+
+// Percentiles zwraca p50/p90/p99/max w formie do wyświetlenia
+func (h *Histogram) Percentiles() string {
+	if h.Count() == 0 {
+		return "brak danych"
+	}
+	round := func(d time.Duration) time.Duration { return d.Round(100 * time.Millisecond) }
+	return fmt.Sprintf("p50 %-8v p90 %-8v p99 %-8v max %v",
+		round(h.Quantile(0.5)), round(h.Quantile(0.9)), round(h.Quantile(0.99)), round(h.Max()))
+}
+
+// Timings grupuje histogramy czasów obsłużonych pojazdów
+type Timings struct {
+	Wait   Histogram // od przyjazdu do dystrybutora
+	Refuel Histogram // na dystrybutorze, z czekaniem na cysternę
+	Total  Histogram // od przyjazdu do zapłaty
+}
+
+// Record zapisuje czasy jednego pojazdu
+func (t *Timings) Record(wait, refuel, total time.Duration) {
+	t.Wait.Record(wait)
+	t.Refuel.Record(refuel)
+	t.Total.Record(total)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucketIndex(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		d     time.Duration
+		index int
+		upper time.Duration // górna granica przedziału
+	}{
+		{-ms, 0, ms - 1},
+		{0, 0, ms - 1},
+		{ms - 1, 0, ms - 1},
+		{ms, 1, 2*ms - 1},
+		{31 * ms, 31, 32*ms - 1}, // ostatni przedział o szerokości 1 ms
+		{32 * ms, 32, 34*ms - 1}, // od 32 ms przedziały po 2 ms
+		{33 * ms, 32, 34*ms - 1},
+		{34 * ms, 33, 36*ms - 1},
+		{63 * ms, 47, 64*ms - 1},
+		{64 * ms, 48, 68*ms - 1}, // od 64 ms przedziały po 4 ms
+		{time.Second, 111, 1024*ms - 1},
+	}
+	for _, tt := range tests {
+		i := bucketIndex(tt.d)
+		if i != tt.index {
+			t.Errorf("bucketIndex(%v) = %d, oczekiwano %d", tt.d, i, tt.index)
+			continue
+		}
+		if upper := bucketUpperBound(i); upper != tt.upper {
+			t.Errorf("bucketUpperBound(%d) = %v, oczekiwano %v", i, upper, tt.upper)
+		}
+	}
+
+	// Przedziały są ciągłe: górna granica każdego przedziału plus 1 ns
+	// należy już do następnego
+	for i := range 200 {
+		if next := bucketIndex(bucketUpperBound(i) + 1); next != i+1 {
+			t.Fatalf("po przedziale %d zaczyna się przedział %d", i, next)
+		}
+	}
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	var h Histogram
+	if h.Quantile(0.5) != 0 || h.Max() != 0 || h.Count() != 0 {
+		t.Fatal("pusty histogram ma niezerowe wartości")
+	}
+
+	ms := time.Millisecond
+	for _, d := range []time.Duration{5 * ms, 10 * ms, 20 * ms, 40 * ms, time.Second} {
+		h.Record(d)
+	}
+	if h.Count() != 5 || h.Max() != time.Second || h.Sum() != 1075*ms {
+		t.Errorf("Count/Max/Sum = %d/%v/%v, oczekiwano 5/1s/1.075s", h.Count(), h.Max(), h.Sum())
+	}
+
+	tests := []struct {
+		q    float64
+		want time.Duration
+	}{
+		{0, 6*ms - 1},    // górna granica przedziału najmniejszego pomiaru
+		{0.5, 21*ms - 1}, // trzeci z pięciu pomiarów
+		{1, time.Second}, // przedział sięga 1024 ms, wynik ogranicza maksimum
+	}
+	for _, tt := range tests {
+		if got := h.Quantile(tt.q); got != tt.want {
+			t.Errorf("Quantile(%v) = %v, oczekiwano %v", tt.q, got, tt.want)
+		}
+	}
+
+	if n := h.CountAtMost(21 * ms); n != 3 {
+		t.Errorf("CountAtMost(21ms) = %d, oczekiwano 3", n)
+	}
+}
@@ -0,0 +1,530 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// sale to obsłużony pojazd ze wszystkim, z czego liczone są statystyki
+// sprzedaży. Powstaje przy dystrybutorze albo z wpisu dziennika.
+type sale struct {
+	vehicle                        *Vehicle
+	pump                           int
+	liters                         float64 // zatankowane paliwo
+	price, cost                    float64 // średnia cena za litr i do zapłaty
+	start, end                     time.Time
+	wait, refuel, blocked, payment time.Duration
+}
+
+// recordSale dolicza obsłużony pojazd do statystyk.
+// Wywoływana z zablokowanym mutexem statystyk.
+func (s *Statistics) recordSale(sl sale) {
+	vehicle := sl.vehicle
+	total := sl.end.Sub(vehicle.ArrivalTime)
+
+	s.ServedVehicles++
+	s.PumpBlockedTime += sl.blocked
+	s.PaymentTime += sl.payment
+	s.Payments[vehicle.Payment]++
+	s.TotalFuelDispensed += sl.liters
+	s.Fuel[vehicle.FuelType].Dispensed += sl.liters
+	s.Fuel[vehicle.FuelType].Revenue += sl.cost
+	order := s.Orders[vehicle.Order]
+	order.Served++
+	order.Liters += sl.liters
+	order.Revenue += sl.cost
+	s.TotalRevenue += sl.cost
+	pump := s.Pumps[sl.pump]
+	if pump == nil {
+		pump = &PumpSales{}
+		s.Pumps[sl.pump] = pump
+	}
+	pump.Served++
+	pump.Liters += sl.liters
+	pump.Revenue += sl.cost
+	s.TotalWaitTime += sl.wait
+	// Zegar rzeczywisty zawsze dolicza opóźnienie planisty goroutines
+	if sl.wait > time.Millisecond {
+		s.WaitedVehicles++
+	}
+	s.AverageWaitTime = s.TotalWaitTime / time.Duration(s.ServedVehicles)
+	hour := &s.Hourly[vehicle.ArrivalTime.Hour()]
+	hour.Served++
+	hour.TotalWaitTime += sl.wait
+	s.ByVehicle[vehicle.Type].Record(sl.wait, sl.refuel, total)
+	s.ByFuel[vehicle.FuelType].Record(sl.wait, sl.refuel, total)
+	s.ByPriority[vehicle.Priority].Record(sl.wait, sl.refuel, total)
+}
+
+// jsonSeconds to czas zapisywany w dzienniku jako liczba sekund
+type jsonSeconds time.Duration
+
+func (s jsonSeconds) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(s).Seconds())
+}
+
+func (s *jsonSeconds) UnmarshalJSON(data []byte) error {
+	var f float64
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	*s = jsonSeconds(math.Round(f * float64(time.Second)))
+	return nil
+}
+
+// Rodzaje wpisów dziennika
+const (
+	journalKindSale   = "sale"   // paragon obsłużonego pojazdu
+	journalKindTotals = "totals" // sumy kontrolne na końcu dziennika
+)
+
+// Transaction to paragon jednego obsłużonego pojazdu - wpis dziennika
+// transakcji. Nazwy paliw, typów pojazdów i płatności są takie jak
+// w scenariuszach i API.
+type Transaction struct {
+	Kind        string      `json:"kind"`
+	Receipt     int         `json:"receipt"` // numer kolejny paragonu
+	VehicleID   int         `json:"vehicle_id"`
+	VehicleType string      `json:"vehicle_type"`
+	Fuel        string      `json:"fuel"`
+	Order       string      `json:"order"` // tryb zamówienia
+	Liters      float64     `json:"liters"`
+	UnitPrice   float64     `json:"unit_price"`
+	Cost        float64     `json:"cost"`
+	Pump        int         `json:"pump"`
+	Payment     string      `json:"payment"`
+	Priority    string      `json:"priority"`
+	Arrival     time.Time   `json:"arrival"`
+	Start       time.Time   `json:"start"` // podjazd do dystrybutora
+	End         time.Time   `json:"end"`   // zapłata
+	Wait        jsonSeconds `json:"wait_seconds"`
+	Refuel      jsonSeconds `json:"refuel_seconds"`
+	Blocked     jsonSeconds `json:"checkout_wait_seconds"` // czekanie na wolną kasę
+	PaymentTime jsonSeconds `json:"payment_seconds"`
+}
+
+// transactionOf zamienia sprzedaż na paragon o numerze receipt
+func transactionOf(receipt int, sl sale) Transaction {
+	vehicle := sl.vehicle
+	return Transaction{
+		Kind:        journalKindSale,
+		Receipt:     receipt,
+		VehicleID:   vehicle.ID,
+		VehicleType: nameOf(vehicleTypeNames, vehicle.Type),
+		Fuel:        fuelTypeKey(vehicle.FuelType),
+		Order:       nameOf(orderModeNames, vehicle.Order),
+		Liters:      sl.liters,
+		UnitPrice:   sl.price,
+		Cost:        sl.cost,
+		Pump:        sl.pump,
+		Payment:     nameOf(paymentMethodNames, vehicle.Payment),
+		Priority:    nameOf(priorityNames, vehicle.Priority),
+		Arrival:     vehicle.ArrivalTime,
+		Start:       sl.start,
+		End:         sl.end,
+		Wait:        jsonSeconds(sl.wait),
+		Refuel:      jsonSeconds(sl.refuel),
+		Blocked:     jsonSeconds(sl.blocked),
+		PaymentTime: jsonSeconds(sl.payment),
+	}
+}
+
+// sale odtwarza sprzedaż z paragonu
+func (tx Transaction) sale() (sale, error) {
+	var errs scenarioErrors
+	vt, ok := vehicleTypeNames[tx.VehicleType]
+	if !ok {
+		errs.add("vehicle_type", "nieznany typ pojazdu %q", tx.VehicleType)
+	}
+	ft, ok := fuelTypeNames[tx.Fuel]
+	if !ok {
+		errs.add("fuel", "nieznany rodzaj paliwa %q", tx.Fuel)
+	}
+	payment, ok := paymentMethodNames[tx.Payment]
+	if !ok {
+		errs.add("payment", "nieznany sposób płatności %q", tx.Payment)
+	}
+	priority, ok := priorityNames[tx.Priority]
+	if !ok {
+		errs.add("priority", "nieznana klasa priorytetu %q", tx.Priority)
+	}
+	order := OrderLiters // dzienniki sprzed trybów zamówień
+	if tx.Order != "" {
+		if order, ok = orderModeNames[tx.Order]; !ok {
+			errs.add("order", "nieznany tryb zamówienia %q", tx.Order)
+		}
+	}
+	if len(errs) > 0 {
+		return sale{}, errors.Join(errs...)
+	}
+
+	return sale{
+		vehicle: &Vehicle{
+			ID:          tx.VehicleID,
+			Type:        vt,
+			FuelType:    ft,
+			FuelAmount:  tx.Liters,
+			ArrivalTime: tx.Arrival,
+			Payment:     payment,
+			Priority:    priority,
+			Order:       order,
+		},
+		pump:    tx.Pump,
+		liters:  tx.Liters,
+		price:   tx.UnitPrice,
+		cost:    tx.Cost,
+		start:   tx.Start,
+		end:     tx.End,
+		wait:    time.Duration(tx.Wait),
+		refuel:  time.Duration(tx.Refuel),
+		blocked: time.Duration(tx.Blocked),
+		payment: time.Duration(tx.PaymentTime),
+	}, nil
+}
+
+// fuelTotals to sumy sprzedaży jednego rodzaju paliwa
+type fuelTotals struct {
+	Liters  float64 `json:"liters"`
+	Revenue float64 `json:"revenue"`
+}
+
+// salesTotals to sumy sprzedaży trybu zamówienia albo dystrybutora
+type salesTotals struct {
+	Served  int     `json:"served"`
+	Liters  float64 `json:"liters"`
+	Revenue float64 `json:"revenue"`
+}
+
+// JournalTotals to sumy kontrolne zamykające dziennik: statystyki sprzedaży
+// stacji w chwili jego zamknięcia - wszystko, co recordSale dolicza
+// z paragonów. Pumps ma klucze będące numerami dystrybutorów, Hourly
+// liczy obsłużone pojazdy według godziny przyjazdu.
+type JournalTotals struct {
+	Kind         string                 `json:"kind"`
+	Transactions int                    `json:"transactions"`
+	Waited       int                    `json:"waited"`
+	Liters       float64                `json:"liters"`
+	Revenue      float64                `json:"revenue"`
+	Wait         jsonSeconds            `json:"wait_seconds"`
+	Blocked      jsonSeconds            `json:"checkout_wait_seconds"`
+	PaymentTime  jsonSeconds            `json:"payment_seconds"`
+	Fuel         map[string]fuelTotals  `json:"fuel"`
+	Payments     map[string]int         `json:"payments"`
+	Orders       map[string]salesTotals `json:"orders"`
+	Pumps        map[string]salesTotals `json:"pumps"`
+	Vehicles     map[string]int         `json:"vehicles"`
+	Priorities   map[string]int         `json:"priorities"`
+	Hourly       []int                  `json:"hourly"`
+}
+
+// totalsOf zwraca sumy kontrolne statystyk.
+// Wywoływana z zablokowanym mutexem statystyk.
+func totalsOf(s *Statistics) JournalTotals {
+	t := JournalTotals{
+		Kind:         journalKindTotals,
+		Transactions: s.ServedVehicles,
+		Waited:       s.WaitedVehicles,
+		Liters:       s.TotalFuelDispensed,
+		Revenue:      s.TotalRevenue,
+		Wait:         jsonSeconds(s.TotalWaitTime),
+		Blocked:      jsonSeconds(s.PumpBlockedTime),
+		PaymentTime:  jsonSeconds(s.PaymentTime),
+		Fuel:         make(map[string]fuelTotals),
+		Payments:     make(map[string]int),
+		Orders:       make(map[string]salesTotals),
+		Pumps:        make(map[string]salesTotals),
+		Vehicles:     make(map[string]int),
+		Priorities:   make(map[string]int),
+		Hourly:       make([]int, len(s.Hourly)),
+	}
+	for _, ft := range allFuelTypes {
+		t.Fuel[fuelTypeKey(ft)] = fuelTotals{Liters: s.Fuel[ft].Dispensed, Revenue: s.Fuel[ft].Revenue}
+	}
+	for _, pm := range allPaymentMethods {
+		t.Payments[nameOf(paymentMethodNames, pm)] = s.Payments[pm]
+	}
+	for _, m := range allOrderModes {
+		o := s.Orders[m]
+		t.Orders[nameOf(orderModeNames, m)] = salesTotals{Served: o.Served, Liters: o.Liters, Revenue: o.Revenue}
+	}
+	for id, p := range s.Pumps {
+		t.Pumps[strconv.Itoa(id)] = salesTotals{Served: p.Served, Liters: p.Liters, Revenue: p.Revenue}
+	}
+	for _, vt := range allVehicleTypes {
+		t.Vehicles[nameOf(vehicleTypeNames, vt)] = int(s.ByVehicle[vt].Total.Count())
+	}
+	for _, p := range allPriorities {
+		t.Priorities[nameOf(priorityNames, p)] = int(s.ByPriority[p].Total.Count())
+	}
+	for h := range s.Hourly {
+		t.Hourly[h] = s.Hourly[h].Served
+	}
+	return t
+}
+
+// Compare zwraca opisy różnic między sumami t (z dziennika) a live
+// (ze statystyk stacji); pusta lista oznacza zgodność
+func (t JournalTotals) Compare(live JournalTotals) []string {
+	var diffs []string
+	number := func(name string, got, want float64) {
+		// Te same sumy w tej samej kolejności - tolerancja tylko na zapis
+		if math.Abs(got-want) > 1e-6*max(1, math.Abs(want)) {
+			diffs = append(diffs, fmt.Sprintf("%s: dziennik %.3f, statystyki %.3f", name, got, want))
+		}
+	}
+	count := func(name string, got, want int) {
+		if got != want {
+			diffs = append(diffs, fmt.Sprintf("%s: dziennik %d, statystyki %d", name, got, want))
+		}
+	}
+	sales := func(name string, got, want salesTotals) {
+		count(name+".served", got.Served, want.Served)
+		number(name+".liters", got.Liters, want.Liters)
+		number(name+".revenue", got.Revenue, want.Revenue)
+	}
+	count("transakcje", t.Transactions, live.Transactions)
+	number("litry", t.Liters, live.Liters)
+	number("przychód", t.Revenue, live.Revenue)
+	number("czas oczekiwania [s]", time.Duration(t.Wait).Seconds(), time.Duration(live.Wait).Seconds())
+	number("czekanie na kasę [s]", time.Duration(t.Blocked).Seconds(), time.Duration(live.Blocked).Seconds())
+	number("czas płatności [s]", time.Duration(t.PaymentTime).Seconds(), time.Duration(live.PaymentTime).Seconds())
+	for _, ft := range allFuelTypes {
+		key := fuelTypeKey(ft)
+		number(key+".liters", t.Fuel[key].Liters, live.Fuel[key].Liters)
+		number(key+".revenue", t.Fuel[key].Revenue, live.Fuel[key].Revenue)
+	}
+	for _, pm := range allPaymentMethods {
+		key := nameOf(paymentMethodNames, pm)
+		count("payments."+key, t.Payments[key], live.Payments[key])
+	}
+
+	// Dzienniki sprzed pełnych sum kontrolnych nie mają tych pól
+	if t.Orders == nil {
+		return diffs
+	}
+	count("czekające pojazdy", t.Waited, live.Waited)
+	for _, m := range allOrderModes {
+		key := nameOf(orderModeNames, m)
+		sales("orders."+key, t.Orders[key], live.Orders[key])
+	}
+	pumps := maps.Clone(t.Pumps)
+	maps.Copy(pumps, live.Pumps)
+	for _, id := range sortedKeys(pumps) {
+		sales("pumps."+id, t.Pumps[id], live.Pumps[id])
+	}
+	for _, vt := range allVehicleTypes {
+		key := nameOf(vehicleTypeNames, vt)
+		count("vehicles."+key, t.Vehicles[key], live.Vehicles[key])
+	}
+	for _, p := range allPriorities {
+		key := nameOf(priorityNames, p)
+		count("priorities."+key, t.Priorities[key], live.Priorities[key])
+	}
+	for h, want := range live.Hourly {
+		got := 0
+		if h < len(t.Hourly) {
+			got = t.Hourly[h]
+		}
+		count(fmt.Sprintf("hourly.%02d", h), got, want)
+	}
+	return diffs
+}
+
+// Journal to dziennik transakcji w formacie JSON Lines: jeden paragon na
+// wiersz, dopisywany tylko na końcu. Record tylko dokłada wpis do listy
+// oczekujących pod własnym mutexem dziennika i nigdy nie czeka na dysk,
+// więc może być wywoływana z zablokowanym mutexem statystyk także na
+// zegarze wirtualnym. Wpisy zapisuje osobna goroutine (write) poza
+// zegarem symulacji; gdy dysk nie nadąża, lista oczekujących rośnie.
+type Journal struct {
+	Path    string
+	file    *os.File
+	mutex   sync.Mutex
+	ready   *sync.Cond // nowe wpisy albo zamknięcie
+	pending []any      // wpisy czekające na zapis
+	closed  bool
+	done    chan struct{}
+	err     error // pierwszy błąd zapisu; czytany po zamknięciu done
+}
+
+// CreateJournal tworzy plik dziennika i uruchamia goroutine zapisu
+func CreateJournal(path string) (*Journal, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	j := &Journal{
+		Path: path,
+		file: file,
+		done: make(chan struct{}),
+	}
+	j.ready = sync.NewCond(&j.mutex)
+	go j.write()
+	return j, nil
+}
+
+// Record dopisuje paragon. Wywoływana z zablokowanym mutexem statystyk,
+// więc kolejność paragonów jest kolejnością doliczania do statystyk.
+func (j *Journal) Record(tx Transaction) {
+	j.mutex.Lock()
+	j.pending = append(j.pending, tx)
+	j.ready.Signal()
+	j.mutex.Unlock()
+}
+
+// Close dopisuje sumy kontrolne, zapisuje resztę dziennika i zamyka plik.
+// Wywoływana po zatrzymaniu stacji, gdy nikt już nie wywoła Record.
+func (j *Journal) Close(totals JournalTotals) error {
+	j.mutex.Lock()
+	j.pending = append(j.pending, totals)
+	j.closed = true
+	j.ready.Signal()
+	j.mutex.Unlock()
+
+	<-j.done
+	return j.err
+}
+
+// write zapisuje oczekujące wpisy partiami, opróżniając bufor pliku po
+// każdej partii. Po błędzie zapisu odrzuca kolejne wpisy.
+func (j *Journal) write() {
+	defer close(j.done)
+
+	w := bufio.NewWriter(j.file)
+	enc := json.NewEncoder(w)
+	for {
+		j.mutex.Lock()
+		for len(j.pending) == 0 && !j.closed {
+			j.ready.Wait()
+		}
+		batch := j.pending
+		j.pending = nil
+		closed := j.closed
+		j.mutex.Unlock()
+
+		for _, record := range batch {
+			if j.err == nil {
+				j.err = enc.Encode(record)
+			}
+		}
+		if j.err == nil {
+			j.err = w.Flush()
+		}
+		if closed {
+			if err := j.file.Close(); j.err == nil {
+				j.err = err
+			}
+			return
+		}
+	}
+}
+
+// ReplayJournal czyta dziennik z pliku path i liczy z paragonów statystyki
+// sprzedaży od nowa. Zwraca je razem z sumami kontrolnymi z końca dziennika
+// (nil, gdy dziennik jest niekompletny, np. po przerwaniu programu).
+func ReplayJournal(path string) (*Statistics, *JournalTotals, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	stats := newStatistics()
+	var totals *JournalTotals
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if totals != nil {
+			return nil, nil, fmt.Errorf("%s:%d: wpis po sumach kontrolnych", path, line)
+		}
+		var header struct {
+			Kind string `json:"kind"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+			return nil, nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		switch header.Kind {
+		case journalKindSale:
+			var tx Transaction
+			if err := json.Unmarshal(scanner.Bytes(), &tx); err != nil {
+				return nil, nil, fmt.Errorf("%s:%d: %w", path, line, err)
+			}
+			sl, err := tx.sale()
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s:%d: %w", path, line, err)
+			}
+			stats.recordSale(sl)
+		case journalKindTotals:
+			totals = &JournalTotals{}
+			if err := json.Unmarshal(scanner.Bytes(), totals); err != nil {
+				return nil, nil, fmt.Errorf("%s:%d: %w", path, line, err)
+			}
+		default:
+			return nil, nil, fmt.Errorf("%s:%d: nieznany rodzaj wpisu %q", path, line, header.Kind)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return stats, totals, nil
+}
+
+// runReplay odtwarza statystyki z dziennika, wypisuje je i sprawdza
+// zgodność z sumami kontrolnymi. Zwraca false przy niezgodności albo
+// niekompletnym dzienniku.
+func runReplay(path string) (bool, error) {
+	stats, totals, err := ReplayJournal(path)
+	if err != nil {
+		return false, err
+	}
+
+	fmt.Printf("\nODTWORZENIE DZIENNIKA %s\n\n", path)
+	fmt.Printf("Paragony:                %d\n", stats.ServedVehicles)
+	fmt.Printf("Łączne zużycie paliwa:   %.2f L\n", stats.TotalFuelDispensed)
+	fmt.Printf("Łączny przychód:         %.2f PLN\n", stats.TotalRevenue)
+	if stats.ServedVehicles > 0 {
+		fmt.Printf("Średni czas oczekiwania: %v\n", stats.AverageWaitTime.Round(time.Millisecond))
+	}
+
+	fmt.Println("\nPaliwo:")
+	for _, ft := range allFuelTypes {
+		fs := stats.Fuel[ft]
+		fmt.Printf("  %-10s wydano %10.2f L, przychód %12.2f PLN\n", ft, fs.Dispensed, fs.Revenue)
+	}
+	fmt.Println("\nDystrybutory:")
+	for _, id := range slices.Sorted(maps.Keys(stats.Pumps)) {
+		ps := stats.Pumps[id]
+		fmt.Printf("  #%-3d %4d pojazdów, wydano %10.2f L, przychód %12.2f PLN\n", id, ps.Served, ps.Liters, ps.Revenue)
+	}
+	fmt.Println("\nKasy:")
+	for _, pm := range allPaymentMethods {
+		fmt.Printf("  %-14s %d płatności\n", pm, stats.Payments[pm])
+	}
+	fmt.Println("\nCzas oczekiwania na dystrybutor:")
+	printTimings(stats, "  ", func(t *Timings) *Histogram { return &t.Wait })
+
+	fmt.Println()
+	if totals == nil {
+		fmt.Println("Brak sum kontrolnych - dziennik jest niekompletny.")
+		return false, nil
+	}
+	diffs := totalsOf(stats).Compare(*totals)
+	if len(diffs) > 0 {
+		fmt.Println("Dziennik NIEZGODNY ze statystykami stacji:")
+		for _, d := range diffs {
+			fmt.Println("  " + d)
+		}
+		return false, nil
+	}
+	fmt.Printf("Dziennik zgodny ze statystykami stacji (%d transakcji, %.2f PLN).\n", totals.Transactions, totals.Revenue)
+	return true, nil
+}
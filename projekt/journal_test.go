@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// journalRun przeprowadza dwie godziny wbudowanego scenariusza
+// z dziennikiem w katalogu tymczasowym i zwraca stację oraz ścieżkę
+// zamkniętego dziennika
+func journalRun(t *testing.T) (*GasStation, string) {
+	t.Helper()
+	s := DefaultScenario()
+	s.Seed = 42
+	s.Duration = 2 * time.Hour
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	journal, err := CreateJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock := NewVirtualClock(simulationStart)
+	gs := NewGasStation(clock, s)
+	gs.Headless = true
+	gs.Journal = journal
+	gs.Start(context.Background())
+	clock.Sleep(s.Duration)
+	gs.Stop(StopDrain)
+
+	if err := journal.Close(totalsOf(gs.Stats)); err != nil {
+		t.Fatal(err)
+	}
+	return gs, path
+}
+
+func TestJournalReplay(t *testing.T) {
+	gs, path := journalRun(t)
+
+	stats, totals, err := ReplayJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if totals == nil {
+		t.Fatal("brak sum kontrolnych na końcu dziennika")
+	}
+	if stats.ServedVehicles == 0 {
+		t.Fatal("dziennik nie zawiera paragonów")
+	}
+	replayed := totalsOf(stats)
+	if diffs := replayed.Compare(*totals); len(diffs) > 0 {
+		t.Errorf("odtworzone statystyki różnią się od sum kontrolnych:\n%s", strings.Join(diffs, "\n"))
+	}
+	if diffs := replayed.Compare(totalsOf(gs.Stats)); len(diffs) > 0 {
+		t.Errorf("odtworzone statystyki różnią się od statystyk stacji:\n%s", strings.Join(diffs, "\n"))
+	}
+	for _, pump := range gs.Pumps {
+		if got := stats.Pumps[pump.ID]; got == nil || got.Served != pump.Served {
+			t.Errorf("dystrybutor %d: odtworzono %+v, obsłużył %d pojazdów", pump.ID, got, pump.Served)
+		}
+	}
+}
+
+func TestJournalReplayDetectsPumpChange(t *testing.T) {
+	_, path := journalRun(t)
+
+	// Przenieś pierwszy paragon na inny dystrybutor: sumy paliw i płatności
+	// się nie zmieniają, różnicę widać tylko w sprzedaży dystrybutorów
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(data), "\n")
+	var tx Transaction
+	if err := json.Unmarshal([]byte(lines[0]), &tx); err != nil {
+		t.Fatal(err)
+	}
+	from := strconv.Itoa(tx.Pump)
+	tx.Pump = tx.Pump%len(DefaultScenario().Pumps) + 1
+	to := strconv.Itoa(tx.Pump)
+	line, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines[0] = string(line)
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, totals, err := ReplayJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields []string
+	for _, d := range totalsOf(stats).Compare(*totals) {
+		field, _, _ := strings.Cut(d, ".")
+		if field != "pumps" {
+			t.Errorf("nieoczekiwana różnica: %s", d)
+		}
+		fields = append(fields, strings.SplitN(d, ":", 2)[0])
+	}
+	for _, id := range []string{from, to} {
+		if !slices.Contains(fields, "pumps."+id+".served") {
+			t.Errorf("brak różnicy liczby pojazdów dystrybutora %s, różnice: %v", id, fields)
+		}
+	}
+}
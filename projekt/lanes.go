@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Fits sprawdza, czy pojazd danego typu mieści się przy dystrybutorze
+func (p *Pump) Fits(vehicleType VehicleType) bool {
+	return slices.Contains(p.Vehicles, vehicleType)
+}
+
+// RefuelTime zwraca czas wydania liters litrów przy wydajności
+// dystrybutora
+func (p *Pump) RefuelTime(liters float64) time.Duration {
+	return time.Duration(liters*(1000/p.FlowRate)) * time.Millisecond
+}
+
+// laneLabel opisuje wydajność i typy obsługiwanych pojazdów dystrybutora,
+// o ile odbiegają od zwykłych, np. " [30 L/s, tylko Ciężarówka]"; dla
+// zwykłego dystrybutora zwraca pusty napis
+func laneLabel(p *Pump) string {
+	var parts []string
+	if p.FlowRate != defaultFlowRate {
+		parts = append(parts, fmt.Sprintf("%g L/s", p.FlowRate))
+	}
+	if len(p.Vehicles) < len(allVehicleTypes) {
+		names := make([]string, len(p.Vehicles))
+		for i, vt := range p.Vehicles {
+			names[i] = vt.String()
+		}
+		parts = append(parts, "tylko "+strings.Join(names, ", "))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(parts, ", ") + "]"
+}
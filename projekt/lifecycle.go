@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// StopMode określa, co dzieje się z pojazdami w kolejce przy zatrzymaniu
+type StopMode int
+
+const (
+	// StopDrain obsługuje wszystkie pojazdy, które już czekają w kolejce
+	StopDrain StopMode = iota
+	// StopAbort odsyła czekające pojazdy i liczy je w TurnedAway
+	StopAbort
+)
+
+func (m StopMode) String() string {
+	switch m {
+	case StopDrain:
+		return "drain"
+	case StopAbort:
+		return "abort"
+	default:
+		return "nieznany"
+	}
+}
+
+// ParseStopMode zamienia nazwę z flagi --stop na tryb zatrzymania
+func ParseStopMode(name string) (StopMode, error) {
+	for _, m := range []StopMode{StopDrain, StopAbort} {
+		if m.String() == name {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("nieznany tryb zatrzymania %q (dozwolone: drain, abort)", name)
+}
+
+// pause usypia goroutine symulacji na d, ale budzi ją wcześniej przy
+// zatrzymaniu stacji. Zwraca false, jeśli stacja została zatrzymana.
+func (gs *GasStation) pause(d time.Duration) bool {
+	elapsed := false
+	timer := gs.Clock.AfterFunc(d, func() {
+		gs.mutex.Lock()
+		elapsed = true
+		gs.stopCond.Broadcast()
+		gs.mutex.Unlock()
+	})
+
+	gs.mutex.Lock()
+	for !elapsed && gs.Running {
+		gs.stopCond.Wait()
+	}
+	running := gs.Running
+	gs.mutex.Unlock()
+
+	timer.Stop()
+	return running
+}
+
+// turnAway odsyła wszystkie pojazdy czekające w kolejce
+func (gs *GasStation) turnAway() {
+	turnedAway := gs.Queue.Clear()
+	for _, vehicle := range turnedAway {
+		if vehicle.patienceTimer != nil {
+			vehicle.patienceTimer.Stop()
+		}
+		gs.publish(EventLeft, vehicle, Event{Reason: LeftTurnedAway})
+	}
+
+	gs.Stats.mutex.Lock()
+	gs.Stats.TurnedAway += len(turnedAway)
+	gs.Stats.mutex.Unlock()
+}
+
+// Stop zatrzymuje stację benzynową. Nowe pojazdy nie są przyjmowane,
+// a te w kolejce zależnie od mode są obsługiwane albo odsyłane. Po
+// powrocie nie działa już żadna goroutine stacji.
+func (gs *GasStation) Stop(mode StopMode) {
+	gs.beginStop(mode)
+	gs.finishStop()
+}
+
+// beginStop zatrzymuje przyjmowanie pojazdów i budzi czekające goroutines,
+// nie czekając na ich zakończenie. Sieć stacji zaczyna w ten sposób
+// zatrzymanie wszystkich stacji w tej samej chwili.
+func (gs *GasStation) beginStop(mode StopMode) {
+	gs.mutex.Lock()
+	gs.Running = false
+	gs.StopTime = gs.Clock.Now()
+	gs.stopMode = mode
+	gs.stopCond.Broadcast()
+	gs.mutex.Unlock()
+
+	// Koniec pracy interfejsu
+	gs.cancel()
+
+	// Nie będzie już awarii ani przeglądów, serwisanci kończą pracę
+	gs.stopMaintenance()
+
+	// Zamknij kolejki - dystrybutory obsłużą tylko pojazdy, które zostały
+	gs.Queue.Close()
+	if mode == StopAbort {
+		gs.turnAway()
+	}
+
+	// Obudź pojazdy czekające na cysternę i zakończ pracę cysterny
+	for _, ft := range allFuelTypes {
+		gs.Tanks[ft].close()
+	}
+	gs.Tanker.Close()
+}
+
+// finishStop czeka na zakończenie goroutines stacji zatrzymanej przez
+// beginStop
+func (gs *GasStation) finishStop() {
+	// Poczekaj na zakończenie wszystkich dystrybutorów
+	gs.Clock.Await(gs.pumpWg.Wait)
+
+	// Pojazdy czekające na zamknięte dystrybutory nie zostaną już obsłużone
+	gs.turnAway()
+
+	// Kierowcy w sklepie i myjni kończą wizyty; kasy i myjnia działają
+	// do ich końca
+	gs.Clock.Await(gs.visitWg.Wait)
+
+	// Dystrybutory ani sklep nie wysyłają już kierowców do kas
+	gs.Checkout.Close()
+	gs.Wash.Close()
+
+	// Generatory, cysterna, serwisanci, próbkowanie i interfejs
+	gs.Clock.Await(gs.wg.Wait)
+
+	// Koniec strumieni zdarzeń
+	gs.Events.Close()
+}
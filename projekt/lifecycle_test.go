@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// stopSaturated uruchamia na 10 minut czasu wirtualnego przesycony
+// scenariusz (kolejka stale pełna, kierowcy nie rezygnują) i zatrzymuje
+// stację w trybie mode. Zwraca stację oraz liczbę pojazdów czekających
+// w kolejce i obsłużonych tuż przed zatrzymaniem.
+func stopSaturated(t *testing.T, mode StopMode) (gs *GasStation, queued, served int) {
+	t.Helper()
+	s, err := LoadScenario("scenarios/saturated.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Duration = 10 * time.Minute
+
+	clock := NewVirtualClock(simulationStart)
+	gs = NewGasStation(clock, s)
+	gs.Headless = true
+	gs.Start(context.Background())
+	clock.Sleep(s.Duration)
+
+	queued, served = gs.Queue.Len(), gs.Stats.ServedVehicles
+	if queued == 0 {
+		t.Fatal("kolejka przesyconego scenariusza jest pusta")
+	}
+	gs.Stop(mode)
+	return gs, queued, served
+}
+
+func TestStopDrainServesQueue(t *testing.T) {
+	gs, queued, served := stopSaturated(t, StopDrain)
+
+	if gs.Queue.Len() != 0 {
+		t.Errorf("po zatrzymaniu w kolejce zostało %d pojazdów", gs.Queue.Len())
+	}
+	if gs.Stats.TurnedAway != 0 {
+		t.Errorf("odesłano %d pojazdów, oczekiwano obsługi wszystkich", gs.Stats.TurnedAway)
+	}
+	lost := gs.Stats.RenegedVehicles
+	if got := gs.Stats.ServedVehicles - served; got < queued-lost {
+		t.Errorf("po zatrzymaniu obsłużono %d pojazdów, w kolejce czekało %d (zrezygnowało %d)", got, queued, lost)
+	}
+}
+
+func TestStopAbortTurnsQueueAway(t *testing.T) {
+	gs, queued, served := stopSaturated(t, StopAbort)
+
+	if gs.Queue.Len() != 0 {
+		t.Errorf("po zatrzymaniu w kolejce zostało %d pojazdów", gs.Queue.Len())
+	}
+	if gs.Stats.TurnedAway != queued {
+		t.Errorf("odesłano %d pojazdów, w kolejce czekało %d", gs.Stats.TurnedAway, queued)
+	}
+	// Przerwane tankowania kończą się płatnością za wydane paliwo, ale
+	// nikt z kolejki nie podjeżdża już do dystrybutora
+	if got := gs.Stats.ServedVehicles - served; got > len(gs.Pumps) {
+		t.Errorf("po zatrzymaniu obsłużono %d pojazdów przy %d dystrybutorach", got, len(gs.Pumps))
+	}
+}
@@ -1,12 +1,18 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"math/rand"
+	"net/http"
 	"os"
-	"os/exec"
-	"runtime"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 )
 
@@ -17,6 +23,7 @@ const (
 	Car VehicleType = iota
 	Truck
 	Motorcycle
+	Emergency // pojazd uprzywilejowany (karetka, policja)
 )
 
 func (vt VehicleType) String() string {
@@ -27,6 +34,8 @@ func (vt VehicleType) String() string {
 		return "Ciężarówka"
 	case Motorcycle:
 		return "Motocykl"
+	case Emergency:
+		return "Uprzywilejowany"
 	default:
 		return "Nieznany"
 	}
@@ -59,107 +68,379 @@ func (ft FuelType) String() string {
 
 // Vehicle reprezentuje pojazd tankujący na stacji
 type Vehicle struct {
-	ID         int
-	Type       VehicleType
-	FuelType   FuelType
-	FuelAmount float64 // litry
+	ID          int
+	Type        VehicleType
+	FuelType    FuelType
+	FuelAmount  float64 // litry
 	ArrivalTime time.Time
+	// WaitsForRefill określa, czy kierowca czeka na cysternę,
+	// gdy zabraknie jego paliwa
+	WaitsForRefill bool
+	Payment        PaymentMethod
+	Priority       Priority
+	// QueueTolerance to najdłuższa kolejka, do której kierowca jeszcze
+	// dołączy; Patience to czas, po którym zrezygnuje z czekania
+	QueueTolerance int
+	Patience       time.Duration
+	patienceTimer  Timer
+	// PriceElasticity mówi, o ile procent mniej paliwa kierowca kupi przy
+	// cenie wyższej o 1% od ceny ze scenariusza; przy cenie wyższej
+	// o więcej niż PriceLimit (ułamek) odjeżdża. Zera - cena nie ma wpływu.
+	PriceElasticity float64
+	PriceLimit      float64
+	// MoneyLimit to kwota, za którą kierowca tankuje najwyżej (0 - bez
+	// limitu); dystrybutor zatrzymuje się, gdy kwota do niej dojdzie.
+	// Przy zamówieniu za kwotę (Order) to zamówiona kwota.
+	MoneyLimit float64
+	// Order to tryb zamówienia; TankCapacity i TankLevel to pojemność
+	// baku i paliwo w nim przy przyjeździe (litry, 0 - bak nieznany)
+	Order        OrderMode
+	TankCapacity float64
+	TankLevel    float64
+	// dispensed i billed to paliwo zatankowane przed awarią dystrybutora
+	// i kwota za nie; po ponownym ustawieniu w kolejce pojazd tankuje już
+	// tylko resztę i ma pierwszeństwo przed wszystkimi (interrupted)
+	dispensed   float64
+	billed      float64
+	interrupted bool
+	// shift to numer zmiany obsługi w chwili przyjazdu (-1 - bez zmian)
+	shift int
 }
 
 // Pump reprezentuje dystrybutor paliwa
 type Pump struct {
-	ID           int
-	FuelTypes    []FuelType
-	IsOccupied   bool
+	ID             int
+	FuelTypes      []FuelType
+	FlowRate       float64       // litry na sekundę
+	Vehicles       []VehicleType // typy pojazdów, które mieszczą się przy dystrybutorze
+	IsOccupied     bool
+	Paying         bool // tankowanie skończone, kierowca płaci przy kasie
+	Closed         bool // wyłączony z obsługi (np. przez API)
+	OffShift       bool // wyłączony przez harmonogram (poza zmianą albo godzinami otwarcia)
+	Broken         bool // awaria, czeka na naprawę
+	InMaintenance  bool // zaplanowany przegląd
 	CurrentVehicle *Vehicle
+	// Liczniki bieżącego tankowania, aktualizowane co takt: zatankowane
+	// litry i kwota do zapłaty (zera - pojazd nie tankuje)
+	CurrentLiters float64
+	CurrentCost   float64
+	// Obciążenie dystrybutora: obsłużone pojazdy, wydane paliwo i łączny
+	// czas zajętości
+	Served    int
+	Dispensed float64 // litry
+	BusyTime  time.Duration
+	busySince time.Time
+	// Awaryjność: średni czas między awariami i naprawy (0 - bez awarii),
+	// liczba awarii i przeglądów, łączny czas przestoju i przychód
+	// utracony w tym czasie
+	MTBF         time.Duration
+	MTTR         time.Duration
+	Failures     int
+	Maintenances int
+	DownTime     time.Duration
+	LostRevenue  float64
+	downSince    time.Time
+	failTimer    Timer
+	rng          *rand.Rand
 	mutex        sync.Mutex
+	cond         Cond // budzi tankowanie przerwane awarią
+}
+
+// FuelStatistics przechowuje statystyki jednego rodzaju paliwa
+type FuelStatistics struct {
+	Dispensed   float64 // litry
+	Revenue     float64
+	StockOuts   int // ile razy zabrakło paliwa dla pojazdu
+	Refills     int // dostawy cysterny
+	LostSales   int // pojazdy, które odjechały bez paliwa
+	LostRevenue float64
+}
+
+// HourStatistics przechowuje statystyki pojazdów przybyłych w danej godzinie doby
+type HourStatistics struct {
+	Arrivals       int
+	Served         int
+	Lost           int // zrezygnowali z kolejki
+	TotalWaitTime  time.Duration
+	MaxQueueLength int
+}
+
+// PumpSales przechowuje sprzedaż jednego dystrybutora
+type PumpSales struct {
+	Served  int
+	Liters  float64
+	Revenue float64
 }
 
 // Statistics przechowuje statystyki stacji
 type Statistics struct {
 	TotalVehicles      int
 	ServedVehicles     int
+	WaitedVehicles     int // obsłużone, które czekały na dystrybutor
 	TotalFuelDispensed float64
 	TotalRevenue       float64
 	AverageWaitTime    time.Duration
 	TotalWaitTime      time.Duration
-	mutex              sync.RWMutex
+	Fuel               map[FuelType]*FuelStatistics
+	// PumpBlockedTime to łączny czas, przez który dystrybutory stały
+	// zajęte, bo kierowcy czekali na wolną kasę
+	PumpBlockedTime time.Duration
+	PaymentTime     time.Duration
+	Payments        map[PaymentMethod]int
+	Hourly          [24]HourStatistics // według godziny przyjazdu
+	// Klienci utraceni przez kolejkę (braki paliwa są w Fuel)
+	BalkedVehicles  int     // odjechali, widząc zbyt długą kolejkę
+	RenegedVehicles int     // odjechali, tracąc cierpliwość w kolejce
+	LostRevenue     float64 // przychód, który przynieśliby utraceni klienci
+	TurnedAway      int     // odesłani z kolejki przy zatrzymaniu (StopAbort)
+	// Kierowcy, którzy odjechali, bo cena przekroczyła ich limit, i ich
+	// niedoszły przychód
+	PriceBalked      int
+	PriceLostRevenue float64
+	// InterruptedRefuels to tankowania przerwane awarią dystrybutora;
+	// DowntimeLostRevenue to część LostRevenue od klientów utraconych,
+	// gdy ich paliwo sprzedawał zepsuty albo serwisowany dystrybutor
+	InterruptedRefuels  int
+	DowntimeLostRevenue float64
+	// Tankowania zakończone przed wydaniem zamówionego paliwa: limitem
+	// kwoty kierowcy i zatrzymaniem stacji w trybie abort
+	LimitedRefuels int
+	AbortedRefuels int
+	// ClosedVehicles to kierowcy, którzy przyjechali do zamkniętej stacji
+	// albo zostali odesłani z kolejki przy zamknięciu; Shifts - jakość
+	// obsługi według zmiany przyjazdu (patrz ScheduleConfig)
+	ClosedVehicles int
+	Shifts         []*ShiftStatistics
+	// Rozkłady czasów obsłużonych pojazdów według typu pojazdu, paliwa
+	// i klasy priorytetu
+	ByVehicle  map[VehicleType]*Timings
+	ByFuel     map[FuelType]*Timings
+	ByPriority map[Priority]*Timings
+	// Sprzedaż według trybu zamówienia i według numeru dystrybutora
+	Orders map[OrderMode]*OrderStatistics
+	Pumps  map[int]*PumpSales
+	// Sklep i myjnia, do których kierowcy trafiają po tankowaniu
+	Shop *StageStatistics
+	Wash *StageStatistics
+	// QueueSamples to szereg czasowy długości kolejek (co SampleInterval)
+	QueueSamples []QueueSample
+	mutex        sync.RWMutex
 }
 
 // GasStation reprezentuje stację benzynową
 type GasStation struct {
-	Pumps         []*Pump
-	Queue         chan *Vehicle
-	Stats         *Statistics
-	Running       bool
-	mutex         sync.RWMutex
-	wg            sync.WaitGroup
-	pumpWg        sync.WaitGroup
+	Clock     Clock
+	Scenario  *Scenario
+	Pricing   *Pricing
+	Pumps     []*Pump
+	Queue     *Dispatcher
+	Tanks     map[FuelType]*Tank
+	Tanker    *Tanker
+	Checkout  *Checkout
+	Crew      *Crew
+	Wash      *CarWash
+	Stats     *Statistics
+	Events    *EventBus
+	Journal   *Journal // dziennik transakcji; nil - bez dziennika
+	Running   bool
+	Headless  bool      // bez interfejsu terminalowego
+	Quit      func()    // wywoływana klawiszem q interfejsu; nil - klawisz nie działa
+	StartTime time.Time // początek pracy stacji według zegara
+	StopTime  time.Time // chwila zatrzymania; obciążenie liczone jest do niej
+	stopMode  StopMode  // tryb zatrzymania; StopAbort przerywa trwające tankowania
+	open      bool      // stacja w godzinach otwarcia
+	shift     int       // numer bieżącej zmiany obsługi (-1 - bez zmian)
+	cancel    context.CancelFunc
+	stopCond  Cond    // budzi goroutines wstrzymane w pause przy zatrzymaniu
+	windows   []Timer // odliczanie do zaplanowanych przeglądów
+	// Losowanie wizyt w sklepie i myjni; visitWg liczy kierowców, którzy
+	// po tankowaniu są jeszcze w sklepie albo myjni
+	serviceRng   *rand.Rand
+	serviceMutex sync.Mutex
+	visitWg      sync.WaitGroup
+	mutex        sync.RWMutex
+	wg           sync.WaitGroup // wszystkie goroutines poza dystrybutorami i kasami
+	pumpWg       sync.WaitGroup
 }
 
-// Ceny paliwa (za litr)
-var fuelPrices = map[FuelType]float64{
-	Gasoline95: 6.50,
-	Gasoline98: 7.20,
-	Diesel:     6.80,
-	LPG:        3.50,
-}
+// Wszystkie rodzaje paliwa w kolejności wyświetlania
+var allFuelTypes = []FuelType{Gasoline95, Gasoline98, Diesel, LPG}
+
+// Wszystkie typy pojazdów w kolejności wyświetlania
+var allVehicleTypes = []VehicleType{Car, Truck, Motorcycle, Emergency}
 
-// NewGasStation tworzy nową stację benzynową
-func NewGasStation(numPumps int) *GasStation {
+// NewGasStation tworzy nową stację benzynową działającą według zegara
+// clock i skonfigurowaną scenariuszem
+func NewGasStation(clock Clock, scenario *Scenario) *GasStation {
 	gs := &GasStation{
-		Pumps:   make([]*Pump, numPumps),
-		Queue:   make(chan *Vehicle, 50),
-		Stats:   &Statistics{},
-		Running: true,
+		Clock:    clock,
+		Scenario: scenario,
+		Pricing:  NewPricing(scenario.Pricing, scenario.Prices),
+		Pumps:    make([]*Pump, len(scenario.Pumps)),
+		Tanks:    make(map[FuelType]*Tank),
+		Tanker:   NewTanker(clock),
+		Checkout: NewCheckout(clock, scenario.Cashiers),
+		Crew:     NewCrew(clock, scenario.Crew),
+		Wash:     NewCarWash(clock, scenario.Wash.Bays),
+		Stats:    newStatistics(),
+		Events:   NewEventBus(clock),
+		Running:  true,
+		open:     true,
+		shift:    -1,
+		// Przesunięcie ziarna nie pokrywa się z generatorami pojazdów
+		// ani dystrybutorów
+		serviceRng: rand.New(rand.NewSource(scenario.Seed + servicesSeedOffset)),
+	}
+
+	// Inicjalizacja zbiorników
+	for _, ft := range allFuelTypes {
+		gs.Tanks[ft] = NewTank(clock, ft, scenario.TankCapacity, scenario.TankRefillLevel, gs.Tanker)
 	}
 
-	// Inicjalizacja dystrybutorów
-	for i := 0; i < numPumps; i++ {
-		gs.Pumps[i] = &Pump{
+	// Inicjalizacja dystrybutorów; każdy losuje awarie własnym generatorem
+	// (ujemne przesunięcie ziarna nie pokrywa się z generatorami pojazdów)
+	for i, config := range scenario.Pumps {
+		pump := &Pump{
 			ID:        i + 1,
-			FuelTypes: []FuelType{Gasoline95, Gasoline98, Diesel, LPG},
+			FuelTypes: config.FuelTypes,
+			FlowRate:  config.FlowRate,
+			Vehicles:  config.Vehicles,
+			MTBF:      config.MTBF,
+			MTTR:      config.MTTR,
+			rng:       rand.New(rand.NewSource(scenario.Seed - int64(i+1))),
 		}
+		pump.cond = clock.NewCond(&pump.mutex)
+		gs.Pumps[i] = pump
+	}
+	gs.Queue = NewDispatcher(clock, gs.Pumps, scenario.QueueCapacity, scenario.PriorityAging)
+	gs.stopCond = clock.NewCond(&gs.mutex)
+	for range scenario.Schedule.Shifts {
+		gs.Stats.Shifts = append(gs.Stats.Shifts, &ShiftStatistics{})
 	}
 
 	return gs
 }
 
-// Start uruchamia stację benzynową
-func (gs *GasStation) Start() {
+// newStatistics tworzy puste statystyki dla wszystkich rodzajów paliwa,
+// typów pojazdów i klas priorytetu
+func newStatistics() *Statistics {
+	s := &Statistics{
+		Fuel:       make(map[FuelType]*FuelStatistics),
+		Payments:   make(map[PaymentMethod]int),
+		ByVehicle:  make(map[VehicleType]*Timings),
+		ByFuel:     make(map[FuelType]*Timings),
+		ByPriority: make(map[Priority]*Timings),
+		Orders:     make(map[OrderMode]*OrderStatistics),
+		Pumps:      make(map[int]*PumpSales),
+		Shop:       &StageStatistics{},
+		Wash:       &StageStatistics{},
+	}
+	for _, ft := range allFuelTypes {
+		s.Fuel[ft] = &FuelStatistics{}
+		s.ByFuel[ft] = &Timings{}
+	}
+	for _, vt := range allVehicleTypes {
+		s.ByVehicle[vt] = &Timings{}
+	}
+	for _, p := range allPriorities {
+		s.ByPriority[p] = &Timings{}
+	}
+	for _, m := range allOrderModes {
+		s.Orders[m] = &OrderStatistics{}
+	}
+	return s
+}
+
+// Start uruchamia stację benzynową. Anulowanie ctx kończy pracę
+// interfejsu; stację zatrzymuje Stop.
+func (gs *GasStation) Start(ctx context.Context) {
+	ctx, gs.cancel = context.WithCancel(ctx)
+	gs.StartTime = gs.Clock.Now()
+
 	// Uruchom goroutines dla każdego dystrybutora
 	for _, pump := range gs.Pumps {
 		gs.pumpWg.Add(1)
-		go gs.runPump(pump)
+		gs.Clock.Go(func() { gs.runPump(pump) })
+	}
+
+	// Goroutines kasjerów i stanowisk myjni
+	gs.Checkout.Start()
+	if gs.Scenario.Wash.Share > 0 {
+		gs.Wash.Start()
+	}
+
+	// Harmonogram otwiera i zamyka dystrybutory i zmienia obsadę kas
+	gs.startSchedule()
+
+	// Ekipa serwisowa, awarie i zaplanowane przeglądy
+	gs.startMaintenance()
+
+	// Przeliczanie cen według strategii
+	gs.startPricing()
+
+	// Goroutine cysterny uzupełniającej zbiorniki
+	gs.goTracked(gs.runTanker)
+
+	// Goroutine próbkująca długość kolejek
+	gs.goTracked(gs.sampleQueues)
+
+	// Goroutines generujące pojazdy, po jednej na typ pojazdu; każda ma
+	// własny generator liczb losowych, więc przebieg zależy tylko od ziarna
+	for i, profile := range gs.Scenario.Vehicles {
+		rng := rand.New(rand.NewSource(gs.Scenario.Seed + int64(i)))
+		gs.goTracked(func() { gs.generateVehicles(profile, rng) })
+	}
+
+	if gs.Headless {
+		return
 	}
 
-	// Goroutine do monitorowania statystyk
-	go gs.monitorStatistics()
+	// Goroutines interfejsu działają poza zegarem symulacji. Na terminalu
+	// interfejs rysuje stan stacji i przyjmuje polecenia z klawiatury,
+	// a gdy wyjście trafia do pliku albo potoku - wypisuje log zdarzeń
+	// i co jakiś czas stan stacji.
+	if isTerminal(os.Stdout) {
+		gs.wg.Add(1)
+		go func() {
+			defer gs.wg.Done()
+			gs.displayUI(ctx)
+		}()
+		return
+	}
 
-	// Goroutine do wyświetlania interfejsu użytkownika
-	go gs.displayUI()
+	sub := gs.Events.Subscribe(256)
+	gs.wg.Add(2)
+	go func() {
+		defer gs.wg.Done()
+		gs.logEvents(ctx, sub)
+	}()
+	go func() {
+		defer gs.wg.Done()
+		gs.monitorStatistics(ctx)
+	}()
+}
+
+// goTracked uruchamia goroutine symulacji, na którą poczeka Stop
+func (gs *GasStation) goTracked(f func()) {
+	gs.wg.Add(1)
+	gs.Clock.Go(func() {
+		defer gs.wg.Done()
+		f()
+	})
 }
 
 // runPump obsługuje pojedynczy dystrybutor
 func (gs *GasStation) runPump(pump *Pump) {
 	defer gs.pumpWg.Done()
 
+	// Czekaj na pojazd z paliwem sprzedawanym przez ten dystrybutor;
+	// nil oznacza zamknięcie kolejek przy zatrzymaniu stacji
 	for {
-		gs.mutex.RLock()
-		running := gs.Running
-		gs.mutex.RUnlock()
-
-		if !running {
+		vehicle := gs.Queue.Next(pump)
+		if vehicle == nil {
 			break
 		}
-
-		// Czekaj na pojazd z kolejki
-		select {
-		case vehicle := <-gs.Queue:
-			gs.serveVehicle(pump, vehicle)
-		case <-time.After(100 * time.Millisecond):
-			// Timeout, aby móc sprawdzić status Running
-		}
+		gs.serveVehicle(pump, vehicle)
 	}
 }
 
@@ -169,229 +450,624 @@ func (gs *GasStation) serveVehicle(pump *Pump, vehicle *Vehicle) {
 	pump.mutex.Lock()
 	pump.IsOccupied = true
 	pump.CurrentVehicle = vehicle
+	pump.busySince = gs.Clock.Now()
+	// Po przerwanym tankowaniu pojazd potrzebuje już tylko reszty
+	remaining := gs.remainingOrder(vehicle)
 	pump.mutex.Unlock()
 
-	waitTime := time.Since(vehicle.ArrivalTime)
+	// Kierowca doczekał się dystrybutora
+	if vehicle.patienceTimer != nil {
+		vehicle.patienceTimer.Stop()
+	}
+
+	refuelStart := gs.Clock.Now()
+	waitTime := refuelStart.Sub(vehicle.ArrivalTime)
+	gs.publish(EventRefuelStarted, vehicle, Event{Pump: pump.ID})
 
-	// Symulacja tankowania (różny czas w zależności od ilości paliwa)
-	refuelingTime := time.Duration(vehicle.FuelAmount*100) * time.Millisecond
-	time.Sleep(refuelingTime)
+	// Pobierz paliwo ze zbiornika (może wymagać czekania na cysternę)
+	tank := gs.Tanks[vehicle.FuelType]
+	ok, stockOut := tank.Take(remaining, vehicle.WaitsForRefill)
+	if stockOut || !ok {
+		lost := gs.cost(vehicle)
+		gs.Stats.mutex.Lock()
+		fuelStats := gs.Stats.Fuel[vehicle.FuelType]
+		if stockOut {
+			fuelStats.StockOuts++
+		}
+		if !ok {
+			fuelStats.LostSales++
+			fuelStats.LostRevenue += lost
+		}
+		gs.Stats.mutex.Unlock()
+	}
+	if !ok {
+		gs.releasePump(pump)
+		gs.publish(EventLeft, vehicle, Event{Pump: pump.ID, Reason: LeftStockOut})
+		return
+	}
 
-	// Oblicz koszt
-	cost := vehicle.FuelAmount * fuelPrices[vehicle.FuelType]
+	// Tankowanie w taktach - czas zależy od ilości paliwa i wydajności
+	// dystrybutora, kwota rośnie według ceny z chwili każdego taktu
+	delivered, outcome := gs.dispense(pump, vehicle, remaining)
+	if outcome == refuelLimit && vehicle.Order == OrderAmount {
+		outcome = refuelCompleted // zamówiona kwota to zwykły koniec tankowania
+	}
+	if delivered < remaining {
+		tank.Return(remaining - delivered)
+	}
+	vehicle.dispensed += delivered
+	pump.mutex.Lock()
+	pump.Dispensed += delivered
+	pump.mutex.Unlock()
+	if outcome == refuelBroken {
+		// Awaria: pojazd zabiera to, co zdążył zatankować, reszta wraca
+		// do zbiornika, a kierowca czeka na innym dystrybutorze
+		gs.releasePump(pump)
 
-	// Aktualizuj statystyki
+		gs.Stats.mutex.Lock()
+		gs.Stats.InterruptedRefuels++
+		gs.Stats.mutex.Unlock()
+		gs.publish(EventRefuelInterrupted, vehicle, Event{Pump: pump.ID, Amount: vehicle.dispensed})
+		vehicle.interrupted = true
+		gs.Queue.Requeue(vehicle)
+		return
+	}
+	refuelTime := gs.Clock.Since(refuelStart)
+	liters, cost := vehicle.dispensed, vehicle.billed
+	// Limit kwoty albo zatrzymanie stacji: kierowca płaci tylko za
+	// zatankowane paliwo
 	gs.Stats.mutex.Lock()
-	gs.Stats.ServedVehicles++
-	gs.Stats.TotalFuelDispensed += vehicle.FuelAmount
-	gs.Stats.TotalRevenue += cost
-	gs.Stats.TotalWaitTime += waitTime
-	gs.Stats.AverageWaitTime = gs.Stats.TotalWaitTime / time.Duration(gs.Stats.ServedVehicles)
+	switch outcome {
+	case refuelLimit:
+		gs.Stats.LimitedRefuels++
+	case refuelAborted:
+		gs.Stats.AbortedRefuels++
+	}
 	gs.Stats.mutex.Unlock()
+	gs.publish(EventRefuelFinished, vehicle, Event{Pump: pump.ID, Amount: liters, Reason: refuelStopReasons[outcome]})
 
-	// Zwolnij dystrybutor
+	// Kierowca idzie do kasy, dystrybutor pozostaje zajęty aż do zapłaty
 	pump.mutex.Lock()
-	pump.IsOccupied = false
-	pump.CurrentVehicle = nil
+	pump.Paying = true
 	pump.mutex.Unlock()
-}
 
-// AddVehicle dodaje pojazd do kolejki
-func (gs *GasStation) AddVehicle(vehicle *Vehicle) {
-	vehicle.ArrivalTime = time.Now()
+	blockedTime, paymentTime := gs.Checkout.Pay(vehicle)
 
+	// Aktualizuj statystyki; dziennik dostaje transakcje w tej samej
+	// kolejności, więc odtworzone z niego sumy są identyczne
+	price := gs.Pricing.Price(vehicle.FuelType)
+	if liters > 0 {
+		price = cost / liters
+	}
+	s := sale{
+		vehicle: vehicle,
+		pump:    pump.ID,
+		liters:  liters,
+		price:   price,
+		cost:    cost,
+		start:   refuelStart,
+		end:     gs.Clock.Now(),
+		wait:    waitTime,
+		refuel:  refuelTime,
+		blocked: blockedTime,
+		payment: paymentTime,
+	}
 	gs.Stats.mutex.Lock()
-	gs.Stats.TotalVehicles++
+	gs.Stats.recordSale(s)
+	if shift := gs.Stats.shiftOf(vehicle); shift != nil {
+		shift.Served++
+		shift.Wait.Record(waitTime)
+		shift.CheckoutWait += blockedTime
+		shift.Revenue += cost
+	}
+	if gs.Journal != nil {
+		gs.Journal.Record(transactionOf(gs.Stats.ServedVehicles, s))
+	}
 	gs.Stats.mutex.Unlock()
 
-	gs.Queue <- vehicle
-}
+	pump.mutex.Lock()
+	pump.Served++
+	pump.mutex.Unlock()
 
-// monitorStatistics monitoruje i loguje statystyki
-func (gs *GasStation) monitorStatistics() {
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
+	gs.releasePump(pump)
+	gs.publish(EventPaid, vehicle, Event{
+		Pump:    pump.ID,
+		Amount:  liters,
+		Cost:    cost,
+		Payment: nameOf(paymentMethodNames, vehicle.Payment),
+	})
 
-	for {
-		gs.mutex.RLock()
-		running := gs.Running
-		gs.mutex.RUnlock()
+	// Część kierowców idzie jeszcze do sklepu albo myjni
+	gs.continueVisit(vehicle)
+}
 
-		if !running {
-			break
-		}
+// releasePump zwalnia dystrybutor
+func (gs *GasStation) releasePump(pump *Pump) {
+	// Tankowanie dokończone po zatrzymaniu stacji nie wlicza się do obciążenia
+	now := gs.Clock.Now()
+	gs.mutex.RLock()
+	if !gs.Running {
+		now = gs.StopTime
+	}
+	gs.mutex.RUnlock()
 
-		<-ticker.C
-		// Statystyki są wyświetlane w displayUI
+	pump.mutex.Lock()
+	pump.BusyTime += max(now.Sub(pump.busySince), 0)
+	pump.IsOccupied = false
+	pump.Paying = false
+	pump.CurrentVehicle = nil
+	pump.CurrentLiters, pump.CurrentCost = 0, 0
+	pump.mutex.Unlock()
+}
+
+// generateVehicles generuje pojazdy jednego typu według profilu ze scenariusza
+func (gs *GasStation) generateVehicles(profile VehicleProfile, rng *rand.Rand) {
+	for gs.pause(profile.Arrival.Next(rng, gs.Clock.Now())) {
+		gs.AddVehicle(profile.NewVehicle(rng))
 	}
 }
 
-// displayUI wyświetla interfejs użytkownika
-func (gs *GasStation) displayUI() {
-	ticker := time.NewTicker(500 * time.Millisecond)
-	defer ticker.Stop()
+// isRunning sprawdza, czy stacja nie została zatrzymana
+func (gs *GasStation) isRunning() bool {
+	gs.mutex.RLock()
+	defer gs.mutex.RUnlock()
+	return gs.Running
+}
 
-	for {
-		gs.mutex.RLock()
-		running := gs.Running
-		gs.mutex.RUnlock()
+// AddVehicle dodaje pojazd do kolejki, nadając mu kolejny numer.
+// Zwraca false, jeśli kierowca nie ustawił się w kolejce.
+func (gs *GasStation) AddVehicle(vehicle *Vehicle) bool {
+	if !gs.isRunning() {
+		return false
+	}
+	vehicle.ArrivalTime = gs.Clock.Now()
+	vehicle.shift = gs.currentShift()
+	gs.prepareOrder(vehicle)
+	lost := gs.cost(vehicle)
 
-		if !running {
-			break
+	gs.Stats.mutex.Lock()
+	gs.Stats.TotalVehicles++
+	vehicle.ID = gs.Stats.TotalVehicles
+	gs.Stats.Hourly[vehicle.ArrivalTime.Hour()].Arrivals++
+	if s := gs.Stats.shiftOf(vehicle); s != nil {
+		s.Arrivals++
+	}
+	gs.Stats.mutex.Unlock()
+	gs.publish(EventArrived, vehicle, Event{})
+
+	// Poza godzinami otwarcia kierowca od razu odjeżdża
+	if !gs.isOpen() {
+		gs.Stats.mutex.Lock()
+		gs.Stats.ClosedVehicles++
+		if s := gs.Stats.shiftOf(vehicle); s != nil {
+			s.Closed++
 		}
+		gs.Stats.mutex.Unlock()
+		gs.publish(EventLeft, vehicle, Event{Reason: LeftClosed})
+		return false
+	}
+
+	if !gs.Queue.Serves(vehicle) {
+		// Żaden dystrybutor, przy którym pojazd się mieści, nie sprzedaje
+		// jego paliwa - kierowca odjeżdża
+		gs.Stats.mutex.Lock()
+		fuelStats := gs.Stats.Fuel[vehicle.FuelType]
+		fuelStats.LostSales++
+		fuelStats.LostRevenue += lost
+		gs.Stats.mutex.Unlock()
+		gs.publish(EventLeft, vehicle, Event{Reason: LeftNoPump})
+		return false
+	}
 
-		<-ticker.C
-		clearScreen()
-
-		fmt.Println(" ")
-		fmt.Println("                    SYMULACJA STACJI BENZYNOWEJ                            ")
-		fmt.Println(" ")
-		fmt.Println()
-
-		// Wyświetl status dystrybutorów
-		fmt.Println(" ")
-		fmt.Println("STATUS DYSTRYBUTORÓW")
-		fmt.Println(" ")
-	
-
-		for _, pump := range gs.Pumps {
-			pump.mutex.Lock()
-			if pump.IsOccupied && pump.CurrentVehicle != nil {
-				fmt.Printf("  Dystrybutor %d: [ZAJĘTY]   Pojazd #%d (%s, %s, %.1fL)\n",
-					pump.ID,
-					pump.CurrentVehicle.ID,
-					pump.CurrentVehicle.Type,
-					pump.CurrentVehicle.FuelType,
-					pump.CurrentVehicle.FuelAmount)
-			} else {
-				fmt.Printf("  Dystrybutor %d: [WOLNY]\n", pump.ID)
+	// Kierowca wrażliwy na cenę tankuje mniej albo odjeżdża
+	if !gs.acceptsPrice(vehicle) {
+		gs.Stats.mutex.Lock()
+		gs.Stats.PriceBalked++
+		gs.Stats.PriceLostRevenue += lost
+		gs.Stats.Hourly[vehicle.ArrivalTime.Hour()].Lost++
+		if s := gs.Stats.shiftOf(vehicle); s != nil {
+			s.Lost++
+		}
+		gs.Stats.mutex.Unlock()
+		gs.publish(EventLeft, vehicle, Event{Reason: LeftPrice})
+		return false
+	}
+
+	// Kierowca, który straci cierpliwość, wyjeżdża z kolejki
+	if vehicle.Patience > 0 {
+		vehicle.patienceTimer = gs.Clock.AfterFunc(vehicle.Patience, func() {
+			if gs.Queue.Remove(vehicle) {
+				gs.recordLostCustomer(vehicle, true)
 			}
-			pump.mutex.Unlock()
+		})
+	}
+
+	if !gs.Queue.Add(vehicle, vehicle.QueueTolerance) {
+		if vehicle.patienceTimer != nil {
+			vehicle.patienceTimer.Stop()
 		}
-		fmt.Println()
-
-		// Wyświetl statystyki
-		gs.Stats.mutex.RLock()
-		fmt.Println("STATYSTYKI")
-		fmt.Println(" ")
-		fmt.Printf("  Pojazdy w kolejce:        %d\n", len(gs.Queue))
-		fmt.Printf("  Pojazdy łącznie:          %d\n", gs.Stats.TotalVehicles)
-		fmt.Printf("  Obsłużone pojazdy:        %d\n", gs.Stats.ServedVehicles)
-		fmt.Printf("  Zużyte paliwo:            %.2f L\n", gs.Stats.TotalFuelDispensed)
-		fmt.Printf("  Przychód:                 %.2f PLN\n", gs.Stats.TotalRevenue)
-		if gs.Stats.ServedVehicles > 0 {
-			fmt.Printf("  Średni czas oczekiwania:  %v\n", gs.Stats.AverageWaitTime.Round(time.Millisecond))
-		} else {
-			fmt.Printf("  Średni czas oczekiwania:  N/A\n")
+		if gs.isRunning() {
+			gs.recordLostCustomer(vehicle, false)
 		}
-		gs.Stats.mutex.RUnlock()
-		fmt.Println()
-		fmt.Println("Naciśnij Ctrl+C aby zakończyć symulację...")
+		return false
 	}
+
+	// Zapamiętaj najdłuższą kolejkę w godzinie przyjazdu
+	queueLength := gs.Queue.Len()
+	gs.Stats.mutex.Lock()
+	hour := &gs.Stats.Hourly[vehicle.ArrivalTime.Hour()]
+	hour.MaxQueueLength = max(hour.MaxQueueLength, queueLength)
+	gs.Stats.mutex.Unlock()
+	return true
 }
 
-// Stop zatrzymuje stację benzynową
-func (gs *GasStation) Stop() {
-	gs.mutex.Lock()
-	gs.Running = false
-	gs.mutex.Unlock()
+// recordLostCustomer zapisuje klienta, który odjechał bez tankowania:
+// od razu na widok kolejki (balking) albo po utracie cierpliwości (reneging)
+func (gs *GasStation) recordLostCustomer(vehicle *Vehicle, reneged bool) {
+	lost := gs.cost(vehicle)
+	reason := LeftBalked
+	if reneged {
+		reason = LeftReneged
+	}
+	gs.publish(EventLeft, vehicle, Event{Reason: reason})
+	downtimeLoss := gs.chargeDowntime(vehicle.FuelType, lost)
 
-	// Poczekaj na zakończenie wszystkich dystrybutorów
-	gs.pumpWg.Wait()
+	gs.Stats.mutex.Lock()
+	defer gs.Stats.mutex.Unlock()
 
-	// Zamknij kolejkę
-	close(gs.Queue)
+	if reneged {
+		gs.Stats.RenegedVehicles++
+	} else {
+		gs.Stats.BalkedVehicles++
+	}
+	gs.Stats.LostRevenue += lost
+	gs.Stats.DowntimeLostRevenue += downtimeLoss
+	gs.Stats.Hourly[vehicle.ArrivalTime.Hour()].Lost++
+	if s := gs.Stats.shiftOf(vehicle); s != nil {
+		s.Lost++
+	}
 }
 
-// generateRandomVehicle generuje losowy pojazd
-func generateRandomVehicle(id int) *Vehicle {
-	vehicleTypes := []VehicleType{Car, Truck, Motorcycle}
-	fuelTypes := []FuelType{Gasoline95, Gasoline98, Diesel, LPG}
+// cost zwraca wartość paliwa pojazdu według bieżącej ceny
+func (gs *GasStation) cost(vehicle *Vehicle) float64 {
+	return vehicle.FuelAmount * gs.Pricing.Price(vehicle.FuelType)
+}
 
-	vType := vehicleTypes[rand.Intn(len(vehicleTypes))]
-	fType := fuelTypes[rand.Intn(len(fuelTypes))]
+// SetPrices zmienia ceny bazowe podanych rodzajów paliwa w trakcie
+// symulacji; strategia cenowa dalej je modyfikuje
+func (gs *GasStation) SetPrices(prices map[FuelType]float64) {
+	gs.publishPrices(gs.Pricing.SetBase(prices))
+}
 
-	var fuelAmount float64
-	switch vType {
-	case Car:
-		fuelAmount = 20 + rand.Float64()*40 // 20-60 litrów
-	case Truck:
-		fuelAmount = 50 + rand.Float64()*150 // 50-200 litrów
-	case Motorcycle:
-		fuelAmount = 5 + rand.Float64()*15 // 5-20 litrów
+// SetPumpOpen otwiera albo zamyka dystrybutor o numerze id. Zamknięty
+// dystrybutor kończy obsługę bieżącego pojazdu i nie przyjmuje kolejnych.
+func (gs *GasStation) SetPumpOpen(id int, open bool) error {
+	if id < 1 || id > len(gs.Pumps) {
+		return fmt.Errorf("nie ma dystrybutora %d", id)
 	}
+	pump := gs.Pumps[id-1]
+
+	pump.mutex.Lock()
+	pump.Closed = !open
+	gs.Queue.SetPumpOnline(pump, pump.inService())
+	pump.mutex.Unlock()
+	return nil
+}
 
-	return &Vehicle{
-		ID:         id,
-		Type:       vType,
-		FuelType:   fType,
-		FuelAmount: fuelAmount,
+// fuelList zwraca listę rodzajów paliwa w formie "(Benzyna 95, Diesel)"
+func fuelList(fuelTypes []FuelType) string {
+	names := make([]string, len(fuelTypes))
+	for i, ft := range fuelTypes {
+		names[i] = ft.String()
 	}
+	return "(" + strings.Join(names, ", ") + ")"
 }
 
-// clearScreen czyści ekran konsoli
-func clearScreen() {
-	var cmd *exec.Cmd
-	if runtime.GOOS == "windows" {
-		cmd = exec.Command("cmd", "/c", "cls")
-	} else {
-		cmd = exec.Command("clear")
+// printTimings wypisuje percentyle jednego z czasów (wybranego przez pick)
+// dla każdego typu pojazdu i rodzaju paliwa, a jeśli na stacji byli
+// klienci uprzywilejowani - także dla klas priorytetu, w których ich obsłużono
+func printTimings(stats *Statistics, indent string, pick func(*Timings) *Histogram) {
+	for _, line := range timingLines(stats, indent, pick) {
+		fmt.Println(line)
+	}
+}
+
+// timingLines zwraca wiersze wypisywane przez printTimings
+func timingLines(stats *Statistics, indent string, pick func(*Timings) *Histogram) []string {
+	var lines []string
+	for _, vt := range allVehicleTypes {
+		lines = append(lines, fmt.Sprintf("%s%-15s %s", indent, vt, pick(stats.ByVehicle[vt]).Percentiles()))
+	}
+	for _, ft := range allFuelTypes {
+		lines = append(lines, fmt.Sprintf("%s%-15s %s", indent, ft, pick(stats.ByFuel[ft]).Percentiles()))
+	}
+	if !stats.usesPriorities() {
+		return lines
 	}
-	cmd.Stdout = os.Stdout
-	cmd.Run()
+	for _, p := range allPriorities {
+		if h := pick(stats.ByPriority[p]); h.Count() > 0 {
+			lines = append(lines, fmt.Sprintf("%s%-15s %s", indent, p, h.Percentiles()))
+		}
+	}
+	return lines
 }
 
+// Początek doby symulowanej w czasie wirtualnym
+var simulationStart = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
 func main() {
-	rand.Seed(time.Now().UnixNano())
+	scenarioPath := flag.String("scenario", "", "plik JSON ze scenariuszem symulacji (domyślnie wbudowany)")
+	seed := flag.Int64("seed", 0, "ziarno generatora liczb losowych (nadpisuje scenariusz; 0 - na podstawie bieżącego czasu)")
+	virtual := flag.Bool("virtual", false, "symulacja w czasie wirtualnym, bez interfejsu (np. cała doba w ułamku sekundy)")
+	duration := flag.Duration("duration", 0, "czas trwania symulacji (nadpisuje scenariusz)")
+	report := flag.Bool("report", false, "porównanie wyników z modelem kolejki M/M/c (Erlang C)")
+	stopMode := flag.String("stop", "drain", "zatrzymanie stacji: drain (obsłuż kolejkę) albo abort (odeślij czekających)")
+	httpAddr := flag.String("http", "", "adres serwera API HTTP, np. :8080 (tylko w czasie rzeczywistym)")
+	csvDir := flag.String("csv", "", "katalog, do którego po symulacji trafią pumps.csv i queue.csv")
+	networkPath := flag.String("network", "", "plik JSON z siecią stacji, między którymi wybierają kierowcy")
+	pricing := flag.String("pricing", "", "strategia cenowa: fixed, time_of_day, demand albo stock (nadpisuje scenariusz)")
+	journalPath := flag.String("journal", "", "plik dziennika transakcji (JSON Lines) z paragonem każdego obsłużonego pojazdu")
+	replayPath := flag.String("replay", "", "odtworzenie statystyk z dziennika transakcji i sprawdzenie ich zgodności")
+	speed := flag.Float64("speed", 1, "tempo czasu symulacji względem rzeczywistego (tylko w czasie rzeczywistym; zmieniane klawiszami +/-)")
+	comparePricingFlag := flag.Bool("compare-pricing", false, "porównanie przychodów wszystkich strategii cenowych na tym samym strumieniu przyjazdów")
+	flag.Parse()
 
-	// Utwórz stację z 4 dystrybutorami
-	station := NewGasStation(4)
+	if *replayPath != "" {
+		ok, err := runReplay(*replayPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+		return
+	}
 
-	// Uruchom stację
-	station.Start()
+	scenario := DefaultScenario()
+	if *scenarioPath != "" {
+		var err error
+		if scenario, err = LoadScenario(*scenarioPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+	if *seed != 0 {
+		scenario.Seed = *seed
+	}
+	if scenario.Seed == 0 {
+		scenario.Seed = time.Now().UnixNano()
+	}
+	if *duration > 0 {
+		scenario.Duration = *duration
+	}
+	if *pricing != "" {
+		if !slices.Contains(pricingStrategies, *pricing) {
+			fmt.Fprintf(os.Stderr, "nieznana strategia cenowa %q (dozwolone: %v)\n", *pricing, pricingStrategies)
+			os.Exit(2)
+		}
+		scenario.Pricing.Strategy = *pricing
+	}
+	mode, err := ParseStopMode(*stopMode)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *speed <= 0 {
+		fmt.Fprintf(os.Stderr, "tempo symulacji musi być dodatnie, jest %g\n", *speed)
+		os.Exit(2)
+	}
+	if *networkPath != "" {
+		if *scenarioPath != "" || *httpAddr != "" || *csvDir != "" || *journalPath != "" || *report || *speed != 1 {
+			fmt.Fprintln(os.Stderr, "--network nie łączy się z --scenario, --http, --csv, --journal, --report ani --speed")
+			os.Exit(2)
+		}
+		if err := runNetwork(*networkPath, *seed, *duration, *virtual, mode); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
+	}
+	if *comparePricingFlag {
+		if *httpAddr != "" || *csvDir != "" || *journalPath != "" || *report {
+			fmt.Fprintln(os.Stderr, "--compare-pricing nie łączy się z --http, --csv, --journal ani --report")
+			os.Exit(2)
+		}
+		comparePricing(scenario)
+		return
+	}
+	if *virtual && *httpAddr != "" {
+		fmt.Fprintln(os.Stderr, "API HTTP działa tylko w czasie rzeczywistym (bez --virtual)")
+		os.Exit(2)
+	}
+	if *virtual && *speed != 1 {
+		fmt.Fprintln(os.Stderr, "--speed działa tylko w czasie rzeczywistym (bez --virtual)")
+		os.Exit(2)
+	}
 
-	// Goroutine generująca pojazdy
-	go func() {
-		vehicleID := 1
-		for {
-			station.mutex.RLock()
-			running := station.Running
-			station.mutex.RUnlock()
-
-			if !running {
-				break
-			}
+	// W czasie rzeczywistym interfejs może wstrzymać i przyspieszyć zegar
+	var clock Clock = NewScaledClock(*speed)
+	if *virtual {
+		clock = NewVirtualClock(simulationStart)
+	}
+
+	// Utwórz stację według scenariusza
+	station := NewGasStation(clock, scenario)
+	station.Headless = *virtual
+	if *journalPath != "" {
+		if station.Journal, err = CreateJournal(*journalPath); err != nil {
+			fmt.Fprintln(os.Stderr, "Błąd utworzenia dziennika:", err)
+			os.Exit(1)
+		}
+	}
+
+	// Ctrl+C (SIGINT) i SIGTERM kończą symulację przed czasem
+	ctx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
 
-			// Generuj nowy pojazd co 1-3 sekundy
-			time.Sleep(time.Duration(1000+rand.Intn(2000)) * time.Millisecond)
+	// Klawisz q w interfejsie kończy symulację jak Ctrl+C
+	ctx, quit := context.WithCancel(ctx)
+	defer quit()
+	station.Quit = quit
 
-			vehicle := generateRandomVehicle(vehicleID)
-			station.AddVehicle(vehicle)
-			vehicleID++
+	// Uruchom stację i API; interfejs zajmuje ekran od startu stacji
+	if !*virtual {
+		fmt.Println("Stacja benzynowa uruchomiona. Naciśnij Ctrl+C aby zakończyć.")
+	}
+	station.Start(ctx)
+	var server *http.Server
+	if *httpAddr != "" {
+		if server, err = ServeAPI(station, *httpAddr); err != nil {
+			station.Stop(StopAbort)
+			fmt.Fprintln(os.Stderr, "Błąd uruchomienia API:", err)
+			os.Exit(1)
 		}
-	}()
+	}
 
-	// Czekaj na przerwanie (Ctrl+C)
-	fmt.Println("Stacja benzynowa uruchomiona. Naciśnij Ctrl+C aby zakończyć.")
-	time.Sleep(60 * time.Second) // Symulacja trwa 60 sekund
+	// Czekaj na koniec symulacji albo przerwanie (Ctrl+C); czas trwania
+	// liczy zegar symulacji, więc pauza i zmiana tempa go wydłużają
+	// albo skracają
+	if *virtual {
+		clock.Sleep(scenario.Duration)
+	} else {
+		finished := make(chan struct{})
+		timer := clock.AfterFunc(scenario.Duration, func() { close(finished) })
+		select {
+		case <-ctx.Done():
+		case <-finished:
+		}
+		timer.Stop()
+	}
+
+	// Kolejne Ctrl+C przerywa program bez czekania na zatrzymanie stacji
+	stopSignals()
 
-	// Zatrzymaj stację
-	station.Stop()
+	// Zatrzymaj stację, a potem API
+	station.Stop(mode)
+	if server != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		server.Shutdown(shutdownCtx)
+		cancel()
+	}
 
 	// Wyświetl ostateczne statystyki
-	clearScreen()
-	fmt.Println("\nPODSUMOWANIE SYMULACJI\n")
+	printSummary(station, *report)
+
+	if station.Journal != nil {
+		station.Stats.mutex.RLock()
+		totals := totalsOf(station.Stats)
+		station.Stats.mutex.RUnlock()
+		if err := station.Journal.Close(totals); err != nil {
+			fmt.Fprintln(os.Stderr, "Błąd zapisu dziennika:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("\nZapisano dziennik %s (%d transakcji).\n", station.Journal.Path, totals.Transactions)
+	}
+
+	if *csvDir != "" {
+		if err := station.WriteCSV(*csvDir); err != nil {
+			fmt.Fprintln(os.Stderr, "Błąd zapisu CSV:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("\nZapisano %s i %s.\n",
+			filepath.Join(*csvDir, "pumps.csv"), filepath.Join(*csvDir, "queue.csv"))
+	}
+
+	fmt.Printf("\nSymulacja zakończona (ziarno: %d).\n", scenario.Seed)
+}
+
+// printSummary wypisuje podsumowanie zatrzymanej stacji, a z report także
+// porównanie z modelem M/M/c
+func printSummary(station *GasStation, report bool) {
+	fmt.Print("\nPODSUMOWANIE SYMULACJI\n\n")
 
 	station.Stats.mutex.RLock()
 	fmt.Printf("Łączna liczba pojazdów:       %d\n", station.Stats.TotalVehicles)
 	fmt.Printf("Obsłużone pojazdy:            %d\n", station.Stats.ServedVehicles)
-	fmt.Printf("Pojazdy w kolejce:            %d\n", len(station.Queue))
+	fmt.Printf("Odjechali na widok kolejki:   %d\n", station.Stats.BalkedVehicles)
+	fmt.Printf("Odjechali po czasie:          %d\n", station.Stats.RenegedVehicles)
+	fmt.Printf("Utracony przychód (kolejka):  %.2f PLN\n", station.Stats.LostRevenue)
+	fmt.Printf("Odesłani przy zamknięciu:     %d\n", station.Stats.TurnedAway)
+	if station.Stats.LimitedRefuels > 0 || station.Stats.AbortedRefuels > 0 {
+		fmt.Printf("Tankowania do limitu kwoty:   %d\n", station.Stats.LimitedRefuels)
+		fmt.Printf("Tankowania przerwane stopem:  %d\n", station.Stats.AbortedRefuels)
+	}
 	fmt.Printf("Łączne zużycie paliwa:        %.2f L\n", station.Stats.TotalFuelDispensed)
 	fmt.Printf("Łączny przychód:              %.2f PLN\n", station.Stats.TotalRevenue)
 	if station.Stats.ServedVehicles > 0 {
 		fmt.Printf("Średni czas oczekiwania:      %v\n", station.Stats.AverageWaitTime.Round(time.Millisecond))
 	}
-	station.Stats.mutex.RUnlock()
 
-	fmt.Println("\nSymulacja zakończona.")
+	fmt.Println("\nKasy:")
+	for _, pm := range allPaymentMethods {
+		fmt.Printf("  %-14s %d płatności\n", pm, station.Stats.Payments[pm])
+	}
+	if station.Stats.ServedVehicles > 0 {
+		served := time.Duration(station.Stats.ServedVehicles)
+		fmt.Printf("  Średni czas płatności:           %v\n", (station.Stats.PaymentTime / served).Round(time.Millisecond))
+		fmt.Printf("  Blokada dystrybutorów przez kasy: %v (średnio %v na pojazd)\n",
+			station.Stats.PumpBlockedTime.Round(time.Millisecond),
+			(station.Stats.PumpBlockedTime / served).Round(time.Millisecond))
+	}
+
+	elapsed := station.StopTime.Sub(station.StartTime)
+	fmt.Println("\nDystrybutory:")
+	for _, pump := range station.Pumps {
+		utilization := pump.Utilization(elapsed)
+		pump.mutex.Lock()
+		fmt.Printf("  %d %-30s obsłużono: %4d, wydano: %8.1f L, zajęty: %5.1f%%, bezczynny: %v%s\n",
+			pump.ID, fuelList(pump.FuelTypes), pump.Served, pump.Dispensed, 100*utilization,
+			(elapsed - pump.BusyTime).Round(time.Second), laneLabel(pump))
+		pump.mutex.Unlock()
+	}
+	fmt.Printf("  Średnia długość kolejki: %.2f\n", station.Stats.AverageQueueLength())
+	if station.failuresEnabled() {
+		printDowntime(station, elapsed)
+	}
+
+	fmt.Println("\nPaliwo:")
+	for _, ft := range allFuelTypes {
+		fs := station.Stats.Fuel[ft]
+		fmt.Printf("  %-10s wydano %8.2f L, braki: %d, dostawy: %d, utracona sprzedaż: %d (%.2f PLN)\n",
+			ft, fs.Dispensed, fs.StockOuts, fs.Refills, fs.LostSales, fs.LostRevenue)
+	}
+	if station.pricingEnabled() {
+		printPricing(station)
+	}
+	if station.ordersEnabled() {
+		printOrders(station)
+	}
+	if station.servicesEnabled() {
+		printServices(station, elapsed)
+	}
+	if station.Scenario.Schedule.enabled() {
+		printSchedule(station)
+	}
+
+	fmt.Println("\nCzas oczekiwania na dystrybutor:")
+	printTimings(station.Stats, "  ", func(t *Timings) *Histogram { return &t.Wait })
+	fmt.Println("\nCzas tankowania:")
+	printTimings(station.Stats, "  ", func(t *Timings) *Histogram { return &t.Refuel })
+	fmt.Println("\nCałkowity czas na stacji:")
+	printTimings(station.Stats, "  ", func(t *Timings) *Histogram { return &t.Total })
+
+	if station.Scenario.Duration >= time.Hour {
+		fmt.Println("\nGodziny (według przyjazdu):")
+		fmt.Println("  Godz.  Przyjazdy  Obsłużone  Zrezygnowali  Śr. oczekiwanie  Maks. kolejka")
+		for h, hs := range station.Stats.Hourly {
+			if hs.Arrivals == 0 {
+				continue
+			}
+			avgWait := time.Duration(0)
+			if hs.Served > 0 {
+				avgWait = hs.TotalWaitTime / time.Duration(hs.Served)
+			}
+			fmt.Printf("  %02d:00  %9d  %9d  %12d  %15v  %13d\n",
+				h, hs.Arrivals, hs.Served, hs.Lost, avgWait.Round(10*time.Millisecond), hs.MaxQueueLength)
+		}
+	}
+	if report {
+		printQueueReport(station)
+	}
+	station.Stats.mutex.RUnlock()
 }
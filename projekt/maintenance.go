@@ -0,0 +1,326 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// repairJob to zlecenie dla ekipy serwisowej: naprawa po awarii albo
+// zaplanowany przegląd dystrybutora
+type repairJob struct {
+	pump        *Pump
+	duration    time.Duration
+	maintenance bool
+}
+
+// Crew to ekipa serwisowa. Zlecenia realizowane są w kolejności zgłoszenia
+// przez Size serwisantów, każdy we własnej goroutine (runMechanic).
+type Crew struct {
+	Size   int
+	jobs   []repairJob
+	busy   int
+	closed bool
+	mutex  sync.Mutex
+	cond   Cond
+}
+
+// NewCrew tworzy ekipę serwisową z size serwisantami
+func NewCrew(clock Clock, size int) *Crew {
+	c := &Crew{Size: size}
+	c.cond = clock.NewCond(&c.mutex)
+	return c
+}
+
+// Request dopisuje zlecenie na koniec kolejki ekipy
+func (c *Crew) Request(job repairJob) {
+	c.mutex.Lock()
+	c.jobs = append(c.jobs, job)
+	c.cond.Signal()
+	c.mutex.Unlock()
+}
+
+// next czeka na kolejne zlecenie; zwraca false po zamknięciu ekipy
+func (c *Crew) next() (repairJob, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for len(c.jobs) == 0 && !c.closed {
+		c.cond.Wait()
+	}
+	if c.closed {
+		return repairJob{}, false
+	}
+	job := c.jobs[0]
+	c.jobs = c.jobs[1:]
+	c.busy++
+	return job, true
+}
+
+// done kończy zlecenie serwisanta
+func (c *Crew) done() {
+	c.mutex.Lock()
+	c.busy--
+	c.mutex.Unlock()
+}
+
+// Status zwraca liczbę pracujących serwisantów i czekających zleceń
+func (c *Crew) Status() (busy, waiting int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.busy, len(c.jobs)
+}
+
+// Close kończy pracę ekipy przy zatrzymaniu stacji; niezrealizowane
+// zlecenia przepadają
+func (c *Crew) Close() {
+	c.mutex.Lock()
+	c.closed = true
+	c.cond.Broadcast()
+	c.mutex.Unlock()
+}
+
+// startMaintenance uruchamia serwisantów, odliczanie do pierwszych awarii
+// i zaplanowane przeglądy
+func (gs *GasStation) startMaintenance() {
+	for range gs.Crew.Size {
+		gs.goTracked(gs.runMechanic)
+	}
+	for _, pump := range gs.Pumps {
+		if pump.MTBF > 0 {
+			pump.mutex.Lock()
+			gs.scheduleFailure(pump)
+			pump.mutex.Unlock()
+		}
+	}
+	for _, w := range gs.Scenario.Maintenance {
+		pump, duration := gs.Pumps[w.Pump-1], w.Duration
+		gs.windows = append(gs.windows, gs.Clock.AfterFunc(w.Start, func() {
+			gs.beginMaintenance(pump, duration)
+		}))
+	}
+}
+
+// stopMaintenance odwołuje przyszłe awarie i przeglądy oraz zwalnia ekipę
+func (gs *GasStation) stopMaintenance() {
+	for _, timer := range gs.windows {
+		timer.Stop()
+	}
+	for _, pump := range gs.Pumps {
+		pump.mutex.Lock()
+		if pump.failTimer != nil {
+			pump.failTimer.Stop()
+		}
+		pump.mutex.Unlock()
+	}
+	gs.Crew.Close()
+}
+
+// scheduleFailure losuje czas do kolejnej awarii dystrybutora (rozkład
+// wykładniczy o średniej MTBF). Wywoływana z zablokowanym mutexem
+// dystrybutora.
+func (gs *GasStation) scheduleFailure(pump *Pump) {
+	ttf := time.Duration(pump.rng.ExpFloat64() * float64(pump.MTBF))
+	pump.failTimer = gs.Clock.AfterFunc(ttf, func() { gs.failPump(pump) })
+}
+
+// failPump psuje dystrybutor: przerywa trwające tankowanie, wyłącza go
+// z obsługi i zgłasza naprawę ekipie. Dystrybutor w trakcie przeglądu
+// się nie psuje - odliczanie zaczyna się od nowa.
+func (gs *GasStation) failPump(pump *Pump) {
+	if !gs.isRunning() {
+		return
+	}
+
+	pump.mutex.Lock()
+	if pump.InMaintenance {
+		gs.scheduleFailure(pump)
+		pump.mutex.Unlock()
+		return
+	}
+	wasDown := pump.down()
+	pump.Broken = true
+	pump.Failures++
+	gs.updatePumpState(pump, wasDown)
+	pump.cond.Broadcast()
+	repairTime := time.Duration(pump.rng.ExpFloat64() * float64(pump.MTTR))
+	pump.mutex.Unlock()
+
+	gs.Crew.Request(repairJob{pump: pump, duration: repairTime})
+}
+
+// beginMaintenance wyłącza dystrybutor na zaplanowany przegląd. Pojazd
+// przy dystrybutorze kończy tankowanie, kolejne czekają na innych.
+func (gs *GasStation) beginMaintenance(pump *Pump, duration time.Duration) {
+	if !gs.isRunning() {
+		return
+	}
+
+	pump.mutex.Lock()
+	wasDown := pump.down()
+	pump.InMaintenance = true
+	pump.Maintenances++
+	gs.updatePumpState(pump, wasDown)
+	pump.mutex.Unlock()
+
+	gs.Crew.Request(repairJob{pump: pump, duration: duration, maintenance: true})
+}
+
+// runMechanic realizuje zlecenia ekipy serwisowej jedno po drugim
+func (gs *GasStation) runMechanic() {
+	for {
+		job, ok := gs.Crew.next()
+		if !ok {
+			return
+		}
+		// Praca przerywana przy zatrzymaniu stacji
+		working := gs.pause(job.duration)
+		gs.Crew.done()
+		if !working {
+			return
+		}
+		gs.restorePump(job)
+	}
+}
+
+// restorePump przywraca dystrybutor do obsługi po naprawie albo
+// przeglądzie; po naprawie zaczyna się odliczanie do kolejnej awarii
+func (gs *GasStation) restorePump(job repairJob) {
+	pump := job.pump
+	pump.mutex.Lock()
+	defer pump.mutex.Unlock()
+
+	wasDown := pump.down()
+	if job.maintenance {
+		pump.InMaintenance = false
+	} else {
+		pump.Broken = false
+		gs.scheduleFailure(pump)
+	}
+	gs.updatePumpState(pump, wasDown)
+}
+
+// updatePumpState liczy przestój dystrybutora i informuje dyspozytora,
+// czy może on przyjmować pojazdy. Wywoływana z zablokowanym mutexem
+// dystrybutora po zmianie Broken, InMaintenance albo Closed.
+func (gs *GasStation) updatePumpState(pump *Pump, wasDown bool) {
+	now := gs.Clock.Now()
+	switch {
+	case !wasDown && pump.down():
+		pump.downSince = now
+	case wasDown && !pump.down():
+		pump.DownTime += max(now.Sub(pump.downSince), 0)
+	}
+	gs.Queue.SetPumpOnline(pump, pump.inService())
+}
+
+// refuel tankuje przez d. Zwraca false, jeśli tankowanie przerwała awaria
+// dystrybutora. Dystrybutor, który się nie psuje, po prostu czeka.
+func (gs *GasStation) refuel(pump *Pump, d time.Duration) bool {
+	if pump.MTBF == 0 {
+		gs.Clock.Sleep(d)
+		return true
+	}
+
+	finished := false
+	timer := gs.Clock.AfterFunc(d, func() {
+		pump.mutex.Lock()
+		finished = true
+		pump.cond.Broadcast()
+		pump.mutex.Unlock()
+	})
+
+	pump.mutex.Lock()
+	for !finished && !pump.Broken {
+		pump.cond.Wait()
+	}
+	pump.mutex.Unlock()
+
+	timer.Stop()
+	return finished
+}
+
+// down sprawdza, czy dystrybutor ma awarię albo przegląd.
+// Wywoływana z zablokowanym mutexem dystrybutora.
+func (p *Pump) down() bool {
+	return p.Broken || p.InMaintenance
+}
+
+// inService sprawdza, czy dystrybutor może przyjmować pojazdy.
+// Wywoływana z zablokowanym mutexem dystrybutora.
+func (p *Pump) inService() bool {
+	return !p.Closed && !p.OffShift && !p.down()
+}
+
+// Downtime zwraca łączny czas awarii i przeglądów do chwili end
+func (p *Pump) Downtime(end time.Time) time.Duration {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	d := p.DownTime
+	if p.down() {
+		d += max(end.Sub(p.downSince), 0)
+	}
+	return d
+}
+
+// Availability zwraca ułamek czasu elapsed (kończącego się w end), przez
+// który dystrybutor był sprawny
+func (p *Pump) Availability(elapsed time.Duration, end time.Time) float64 {
+	if elapsed <= 0 {
+		return 1
+	}
+	return 1 - float64(p.Downtime(end))/float64(elapsed)
+}
+
+// chargeDowntime przypisuje przychód lost utraconego klienta zepsutym
+// i serwisowanym dystrybutorom sprzedającym jego paliwo (po równo).
+// Zwraca przypisaną kwotę - zero, gdy wszystkie takie dystrybutory działały.
+func (gs *GasStation) chargeDowntime(fuelType FuelType, lost float64) float64 {
+	var down []*Pump
+	for _, pump := range gs.Pumps {
+		pump.mutex.Lock()
+		if pump.down() && slices.Contains(pump.FuelTypes, fuelType) {
+			down = append(down, pump)
+		}
+		pump.mutex.Unlock()
+	}
+	if len(down) == 0 {
+		return 0
+	}
+
+	share := lost / float64(len(down))
+	for _, pump := range down {
+		pump.mutex.Lock()
+		pump.LostRevenue += share
+		pump.mutex.Unlock()
+	}
+	return lost
+}
+
+// failuresEnabled sprawdza, czy scenariusz przewiduje awarie albo przeglądy
+func (gs *GasStation) failuresEnabled() bool {
+	for _, pump := range gs.Pumps {
+		if pump.MTBF > 0 {
+			return true
+		}
+	}
+	return len(gs.Scenario.Maintenance) > 0
+}
+
+// printDowntime wypisuje w podsumowaniu awarie, przeglądy i dostępność
+// dystrybutorów. Wywoływana z zablokowanym mutexem statystyk.
+func printDowntime(gs *GasStation, elapsed time.Duration) {
+	fmt.Printf("\nAwarie i przeglądy (serwisanci: %d):\n", gs.Crew.Size)
+	for _, pump := range gs.Pumps {
+		down := pump.Downtime(gs.StopTime)
+		availability := pump.Availability(elapsed, gs.StopTime)
+		pump.mutex.Lock()
+		fmt.Printf("  %d  awarie: %3d, przeglądy: %2d, przestój: %8v, dostępność: %5.1f%%, utracony przychód: %.2f PLN\n",
+			pump.ID, pump.Failures, pump.Maintenances, down.Round(time.Second), 100*availability, pump.LostRevenue)
+		pump.mutex.Unlock()
+	}
+	fmt.Printf("  Przerwane tankowania: %d\n", gs.Stats.InterruptedRefuels)
+	fmt.Printf("  Utracony przychód (przestoje): %.2f PLN\n", gs.Stats.DowntimeLostRevenue)
+}
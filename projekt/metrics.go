@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueueSample to stan kolejek w jednej chwili symulacji
+type QueueSample struct {
+	Elapsed   time.Duration // od startu stacji
+	Queue     int
+	Lines     [4]int // według allFuelTypes
+	BusyPumps int
+	Checkout  int // kierowcy czekający na wolną kasę
+}
+
+// sampleQueues co Scenario.SampleInterval zapisuje długość kolejek
+// i liczbę zajętych dystrybutorów
+func (gs *GasStation) sampleQueues() {
+	for {
+		sample := QueueSample{
+			Elapsed: gs.Clock.Since(gs.StartTime),
+			Queue:   gs.Queue.Len(),
+		}
+		for i, ft := range allFuelTypes {
+			sample.Lines[i] = gs.Queue.LineLen(ft)
+		}
+		for _, pump := range gs.Pumps {
+			pump.mutex.Lock()
+			if pump.IsOccupied {
+				sample.BusyPumps++
+			}
+			pump.mutex.Unlock()
+		}
+		_, sample.Checkout = gs.Checkout.Status()
+
+		gs.Stats.mutex.Lock()
+		gs.Stats.QueueSamples = append(gs.Stats.QueueSamples, sample)
+		gs.Stats.mutex.Unlock()
+
+		if !gs.pause(gs.Scenario.SampleInterval) {
+			return
+		}
+	}
+}
+
+// AverageQueueLength zwraca średnią długość kolejki z próbek.
+// Wywoływana z zablokowanym mutexem statystyk.
+func (s *Statistics) AverageQueueLength() float64 {
+	if len(s.QueueSamples) == 0 {
+		return 0
+	}
+	total := 0
+	for _, sample := range s.QueueSamples {
+		total += sample.Queue
+	}
+	return float64(total) / float64(len(s.QueueSamples))
+}
+
+// Utilization zwraca ułamek czasu elapsed, przez który dystrybutor był zajęty
+func (p *Pump) Utilization(elapsed time.Duration) float64 {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(p.BusyTime) / float64(elapsed)
+}
+
+// WriteCSV zapisuje w katalogu dir pliki pumps.csv (obciążenie
+// dystrybutorów) i queue.csv (szereg czasowy długości kolejek)
+func (gs *GasStation) WriteCSV(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	elapsed := gs.StopTime.Sub(gs.StartTime)
+
+	pumps := [][]string{{"pump", "fuels", "flow_rate", "vehicles", "served", "dispensed_l",
+		"busy_s", "idle_s", "utilization", "failures", "maintenances", "down_s", "availability"}}
+	for _, pump := range gs.Pumps {
+		utilization := pump.Utilization(elapsed)
+		down := pump.Downtime(gs.StopTime)
+		availability := pump.Availability(elapsed, gs.StopTime)
+		pump.mutex.Lock()
+		pumps = append(pumps, []string{
+			strconv.Itoa(pump.ID),
+			fuelKeys(pump.FuelTypes),
+			strconv.FormatFloat(pump.FlowRate, 'f', -1, 64),
+			vehicleKeys(pump.Vehicles),
+			strconv.Itoa(pump.Served),
+			strconv.FormatFloat(pump.Dispensed, 'f', 1, 64),
+			seconds(pump.BusyTime),
+			seconds(elapsed - pump.BusyTime),
+			strconv.FormatFloat(utilization, 'f', 4, 64),
+			strconv.Itoa(pump.Failures),
+			strconv.Itoa(pump.Maintenances),
+			seconds(down),
+			strconv.FormatFloat(availability, 'f', 4, 64),
+		})
+		pump.mutex.Unlock()
+	}
+
+	header := []string{"elapsed_s", "queue"}
+	for _, ft := range allFuelTypes {
+		header = append(header, "queue_"+fuelTypeKey(ft))
+	}
+	header = append(header, "busy_pumps", "checkout_queue")
+	queue := [][]string{header}
+
+	gs.Stats.mutex.RLock()
+	for _, sample := range gs.Stats.QueueSamples {
+		row := []string{seconds(sample.Elapsed), strconv.Itoa(sample.Queue)}
+		for _, n := range sample.Lines {
+			row = append(row, strconv.Itoa(n))
+		}
+		row = append(row, strconv.Itoa(sample.BusyPumps), strconv.Itoa(sample.Checkout))
+		queue = append(queue, row)
+	}
+	gs.Stats.mutex.RUnlock()
+
+	if err := writeCSVFile(filepath.Join(dir, "pumps.csv"), pumps); err != nil {
+		return err
+	}
+	return writeCSVFile(filepath.Join(dir, "queue.csv"), queue)
+}
+
+// writeCSVFile zapisuje wiersze do pliku CSV
+func writeCSVFile(path string, rows [][]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	w.WriteAll(rows)
+	if err := w.Error(); err != nil {
+		f.Close()
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// seconds zapisuje czas w sekundach, np. "12.500"
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
+
+// fuelKeys zwraca nazwy paliw oddzielone spacją, np. "gasoline95 diesel"
+func fuelKeys(fuelTypes []FuelType) string {
+	keys := make([]string, len(fuelTypes))
+	for i, ft := range fuelTypes {
+		keys[i] = fuelTypeKey(ft)
+	}
+	return strings.Join(keys, " ")
+}
+
+// vehicleKeys łączy nazwy typów pojazdów spacjami (np. "car truck")
+func vehicleKeys(vehicleTypes []VehicleType) string {
+	keys := make([]string, len(vehicleTypes))
+	for i, vt := range vehicleTypes {
+		keys[i] = nameOf(vehicleTypeNames, vt)
+	}
+	return strings.Join(keys, " ")
+}
+
+// fuelTypeKey zwraca nazwę paliwa używaną w plikach (np. "gasoline95")
+func fuelTypeKey(ft FuelType) string {
+	return nameOf(fuelTypeNames, ft)
+}
@@ -0,0 +1,497 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"math/rand"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Routing opisuje, jak kierowca wybiera stację w sieci. Każda stacja
+// dostaje koszt uogólniony (PLN): wartość paliwa według jej ceny, dojazd
+// (PerKm za kilometr) i czekanie (PerVehicle za każdy pojazd w kolejce).
+// Kierowca wybiera stację z prawdopodobieństwem proporcjonalnym do
+// exp(-koszt/Spread), więc przy Spread = 0 zawsze najtańszą.
+type Routing struct {
+	PerKm      float64
+	PerVehicle float64
+	Spread     float64
+}
+
+// StationConfig opisuje stację w sieci: nazwę, odległość od trasy
+// i scenariusz (dystrybutory, ceny, zbiorniki, kasy)
+type StationConfig struct {
+	Name     string
+	Distance float64 // km
+	Scenario *Scenario
+}
+
+// NetworkScenario opisuje sieć stacji wzdłuż trasy. Pojazdy przyjeżdżają
+// do sieci, nie do pojedynczej stacji, i same wybierają stację (Routing).
+type NetworkScenario struct {
+	Duration time.Duration
+	Seed     int64
+	Vehicles []VehicleProfile
+	Routing  Routing
+	Stations []StationConfig
+}
+
+// Network to kilka stacji działających według jednego zegara, między
+// którymi kierowcy wybierają
+type Network struct {
+	Clock     Clock
+	Scenario  *NetworkScenario
+	Stations  []*GasStation
+	Chosen    []int // pojazdy skierowane do każdej stacji, chronione mutexem
+	Running   bool
+	StartTime time.Time
+	StopTime  time.Time
+	stopCond  Cond // budzi generatory przy zatrzymaniu
+	mutex     sync.Mutex
+	wg        sync.WaitGroup
+}
+
+// Przesunięcie ziarna kolejnych stacji sieci, żeby awarie dystrybutorów
+// nie powtarzały się na wszystkich stacjach jednocześnie
+const stationSeedStride = 1000
+
+// NewNetwork tworzy stacje sieci działające według zegara clock. Stacje
+// nie generują własnych pojazdów - dostają je od generatorów sieci.
+func NewNetwork(clock Clock, ns *NetworkScenario) *Network {
+	n := &Network{
+		Clock:    clock,
+		Scenario: ns,
+		Chosen:   make([]int, len(ns.Stations)),
+		Running:  true,
+	}
+	for i, config := range ns.Stations {
+		scenario := *config.Scenario
+		scenario.Duration = ns.Duration
+		scenario.Seed = ns.Seed + int64(i+1)*stationSeedStride
+		scenario.Vehicles = nil
+
+		station := NewGasStation(clock, &scenario)
+		station.Headless = true
+		n.Stations = append(n.Stations, station)
+	}
+	n.stopCond = clock.NewCond(&n.mutex)
+	return n
+}
+
+// Start uruchamia wszystkie stacje i generatory pojazdów sieci
+func (n *Network) Start(ctx context.Context) {
+	n.StartTime = n.Clock.Now()
+	for _, station := range n.Stations {
+		station.Start(ctx)
+	}
+
+	// Generatory jak w GasStation.Start: po jednym na strumień pojazdów,
+	// każdy z własnym generatorem liczb losowych
+	for i, profile := range n.Scenario.Vehicles {
+		rng := rand.New(rand.NewSource(n.Scenario.Seed + int64(i)))
+		n.wg.Add(1)
+		n.Clock.Go(func() {
+			defer n.wg.Done()
+			n.generateVehicles(profile, rng)
+		})
+	}
+}
+
+// Stop zatrzymuje generatory i wszystkie stacje w tej samej chwili,
+// a potem czeka, aż stacje zakończą pracę (według mode)
+func (n *Network) Stop(mode StopMode) {
+	n.mutex.Lock()
+	n.Running = false
+	n.StopTime = n.Clock.Now()
+	n.stopCond.Broadcast()
+	n.mutex.Unlock()
+
+	for _, station := range n.Stations {
+		station.beginStop(mode)
+	}
+	n.Clock.Await(n.wg.Wait)
+	for _, station := range n.Stations {
+		station.finishStop()
+	}
+}
+
+// pause usypia generator na d, ale budzi go wcześniej przy zatrzymaniu
+// sieci. Zwraca false, jeśli sieć została zatrzymana.
+func (n *Network) pause(d time.Duration) bool {
+	elapsed := false
+	timer := n.Clock.AfterFunc(d, func() {
+		n.mutex.Lock()
+		elapsed = true
+		n.stopCond.Broadcast()
+		n.mutex.Unlock()
+	})
+
+	n.mutex.Lock()
+	for !elapsed && n.Running {
+		n.stopCond.Wait()
+	}
+	running := n.Running
+	n.mutex.Unlock()
+
+	timer.Stop()
+	return running
+}
+
+// generateVehicles generuje pojazdy jednego strumienia i kieruje je do
+// wybranych stacji
+func (n *Network) generateVehicles(profile VehicleProfile, rng *rand.Rand) {
+	for n.pause(profile.Arrival.Next(rng, n.Clock.Now())) {
+		vehicle := profile.NewVehicle(rng)
+		i := n.route(vehicle, rng)
+
+		n.mutex.Lock()
+		n.Chosen[i]++
+		n.mutex.Unlock()
+		n.Stations[i].AddVehicle(vehicle)
+	}
+}
+
+// route wybiera stację dla pojazdu spośród tych, które mogą go obsłużyć
+// (paliwo i miejsce przy dystrybutorze). Gdy żadna nie może, kierowca
+// jedzie do najtańszej i tam odjeżdża bez tankowania.
+func (n *Network) route(vehicle *Vehicle, rng *rand.Rand) int {
+	routing := n.Scenario.Routing
+	costs := make([]float64, len(n.Stations))
+	candidates := make([]int, 0, len(n.Stations))
+	for i, station := range n.Stations {
+		costs[i] = station.cost(vehicle) +
+			routing.PerKm*n.Scenario.Stations[i].Distance +
+			routing.PerVehicle*float64(station.Queue.Len())
+		if station.Queue.Serves(vehicle) {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		for i := range n.Stations {
+			candidates = append(candidates, i)
+		}
+	}
+
+	best := candidates[0]
+	for _, i := range candidates {
+		if costs[i] < costs[best] {
+			best = i
+		}
+	}
+	if routing.Spread == 0 {
+		return best
+	}
+
+	// Model logitowy: wagi względem najtańszej stacji, żeby exp nie
+	// zaokrąglił wszystkich do zera
+	weights := make([]float64, len(candidates))
+	total := 0.0
+	for j, i := range candidates {
+		weights[j] = math.Exp(-(costs[i] - costs[best]) / routing.Spread)
+		total += weights[j]
+	}
+	r := rng.Float64() * total
+	for j, i := range candidates {
+		if r < weights[j] {
+			return i
+		}
+		r -= weights[j]
+	}
+	return candidates[len(candidates)-1]
+}
+
+// PrintSummary wypisuje udział w rynku, przychód i czasy oczekiwania
+// każdej stacji sieci
+func (n *Network) PrintSummary() {
+	n.mutex.Lock()
+	chosen := append([]int(nil), n.Chosen...)
+	n.mutex.Unlock()
+
+	totalChosen, totalRevenue := 0, 0.0
+	for i, station := range n.Stations {
+		totalChosen += chosen[i]
+		station.Stats.mutex.RLock()
+		totalRevenue += station.Stats.TotalRevenue
+		station.Stats.mutex.RUnlock()
+	}
+
+	fmt.Print("\nPODSUMOWANIE SIECI STACJI\n\n")
+	fmt.Printf("Pojazdy w sieci: %d\n", totalChosen)
+	fmt.Printf("Łączny przychód: %.2f PLN\n\n", totalRevenue)
+	fmt.Println("  Stacja          Odległość  Pojazdy  Udział  Obsłużone  Utraceni     Przychód  Udział w przychodzie  Śr. oczekiwanie")
+	for i, station := range n.Stations {
+		config := n.Scenario.Stations[i]
+		station.Stats.mutex.RLock()
+		stats := station.Stats
+		lost := stats.BalkedVehicles + stats.RenegedVehicles + stats.TurnedAway
+		for _, ft := range allFuelTypes {
+			lost += stats.Fuel[ft].LostSales
+		}
+		fmt.Printf("  %-15s %6.1f km  %7d  %5.1f%%  %9d  %8d  %11.2f  %19.1f%%  %15v\n",
+			config.Name, config.Distance, chosen[i], percent(chosen[i], totalChosen),
+			stats.ServedVehicles, lost, stats.TotalRevenue, 100*share(stats.TotalRevenue, totalRevenue),
+			stats.AverageWaitTime.Round(10*time.Millisecond))
+		station.Stats.mutex.RUnlock()
+	}
+
+	fmt.Println("\nCeny (PLN/L):")
+	fmt.Print("  Stacja         ")
+	for _, ft := range allFuelTypes {
+		fmt.Printf(" %10s", ft)
+	}
+	fmt.Println()
+	for i, station := range n.Stations {
+		fmt.Printf("  %-15s", n.Scenario.Stations[i].Name)
+		prices := station.Pricing.Prices()
+		for _, ft := range allFuelTypes {
+			if price, ok := prices[ft]; ok {
+				fmt.Printf(" %10.2f", price)
+			} else {
+				fmt.Printf(" %10s", "-")
+			}
+		}
+		fmt.Println()
+	}
+
+	fmt.Println("\nCzas oczekiwania na dystrybutor:")
+	for i, station := range n.Stations {
+		fmt.Printf("  %s (dystrybutory: %d):\n", n.Scenario.Stations[i].Name, len(station.Pumps))
+		station.Stats.mutex.RLock()
+		printTimings(station.Stats, "    ", func(t *Timings) *Histogram { return &t.Wait })
+		station.Stats.mutex.RUnlock()
+	}
+}
+
+// percent zwraca part jako procent whole (0 dla pustej całości)
+func percent(part, whole int) float64 {
+	return 100 * share(float64(part), float64(whole))
+}
+
+// share zwraca ułamek part/whole (0 dla pustej całości)
+func share(part, whole float64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return part / whole
+}
+
+// Pliki sieci stacji. Pola najwyższego poziomu (poza routing i stations)
+// to scenariusz wspólny: czas trwania, ziarno, strumienie pojazdów
+// i domyślna konfiguracja stacji, którą każda stacja może nadpisać.
+type networkFile struct {
+	scenarioFile
+	Routing  routingFile       `json:"routing"`
+	Stations []json.RawMessage `json:"stations"`
+}
+
+type routingFile struct {
+	PerKm      float64 `json:"per_km"`
+	PerVehicle float64 `json:"per_vehicle"`
+	Spread     float64 `json:"spread"`
+}
+
+type stationFile struct {
+	scenarioFile
+	Name     string  `json:"name"`
+	Distance float64 `json:"distance_km"`
+}
+
+// Domyślne parametry wyboru stacji: dojazd kosztuje tyle co paliwo na
+// kilometr, pojazd w kolejce to ok. minuta czekania
+var defaultRouting = routingFile{PerKm: 0.8, PerVehicle: 2, Spread: 5}
+
+// LoadNetwork wczytuje sieć stacji z pliku JSON i sprawdza jej poprawność.
+// Błędy stacji wskazują jej pole, np. stations[1].pumps[0].fuels[0].
+func LoadNetwork(path string) (*NetworkScenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Listy zastępują domyślne w całości, jak w LoadScenario
+	defaults := defaultScenarioFile()
+	file := networkFile{scenarioFile: defaults, Routing: defaultRouting}
+	file.Pumps, file.Vehicles = nil, nil
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, describeJSONError(data, err))
+	}
+	if file.Pumps == nil {
+		file.Pumps = defaults.Pumps
+	}
+	if file.Vehicles == nil {
+		file.Vehicles = defaults.Vehicles
+	}
+
+	ns, err := file.build()
+	if err != nil {
+		return nil, fmt.Errorf("%s: błędna sieć stacji:\n%w", path, err)
+	}
+	return ns, nil
+}
+
+// build sprawdza sieć i zamienia ją na postać używaną przez Network
+func (f networkFile) build() (*NetworkScenario, error) {
+	var errs []error
+	common, err := f.scenarioFile.build()
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	var routingErrs scenarioErrors
+	for _, r := range []struct {
+		field string
+		value float64
+	}{
+		{"routing.per_km", f.Routing.PerKm},
+		{"routing.per_vehicle", f.Routing.PerVehicle},
+		{"routing.spread", f.Routing.Spread},
+	} {
+		if r.value < 0 {
+			routingErrs.add(r.field, "nie może być ujemne, jest %g", r.value)
+		}
+	}
+	if len(f.Stations) == 0 {
+		routingErrs.add("stations", "potrzebna jest co najmniej jedna stacja")
+	}
+	errs = append(errs, routingErrs...)
+
+	ns := &NetworkScenario{
+		Routing: Routing{
+			PerKm:      f.Routing.PerKm,
+			PerVehicle: f.Routing.PerVehicle,
+			Spread:     f.Routing.Spread,
+		},
+	}
+	names := make(map[string]bool)
+	for i, data := range f.Stations {
+		field := fmt.Sprintf("stations[%d]", i)
+		config, err := f.buildStation(field, data)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if config.Name == "" {
+			config.Name = fmt.Sprintf("Stacja %d", i+1)
+		}
+		if names[config.Name] {
+			errs = append(errs, fmt.Errorf("  %s.name: nazwa %q powtarza się", field, config.Name))
+		}
+		names[config.Name] = true
+		ns.Stations = append(ns.Stations, config)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	ns.Duration, ns.Seed, ns.Vehicles = common.Duration, common.Seed, common.Vehicles
+	return ns, nil
+}
+
+// buildStation nakłada ustawienia stacji na scenariusz wspólny. Listy
+// (pumps, maintenance.windows) zastępują wspólne w całości, ceny są
+// uzupełniane pojedynczo. Czas trwania, ziarno i pojazdy są wspólne dla
+// całej sieci. Błędy wskazują pola poprzedzone ścieżką field.
+func (f networkFile) buildStation(field string, data json.RawMessage) (StationConfig, error) {
+	file := stationFile{scenarioFile: f.scenarioFile}
+	file.Prices = maps.Clone(f.Prices)
+	file.Pumps, file.Maintenance.Windows = nil, nil
+	file.Duration, file.Seed, file.Vehicles = "", 0, nil
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return StationConfig{}, fmt.Errorf("  %s: %w", field, describeJSONError(data, err))
+	}
+
+	var errs scenarioErrors
+	if file.Duration != "" || file.Seed != 0 || file.Vehicles != nil {
+		errs.add(field, "duration, seed i vehicles podaje się dla całej sieci, nie dla stacji")
+	}
+	if file.Distance < 0 {
+		errs.add(field+".distance_km", "nie może być ujemna, jest %g", file.Distance)
+	}
+	if file.Pumps == nil {
+		file.Pumps = f.Pumps
+	}
+	if file.Maintenance.Windows == nil {
+		file.Maintenance.Windows = f.Maintenance.Windows
+	}
+	// Pojazdy stacji są pomijane (przyjeżdżają z sieci), ale build wymaga
+	// poprawnej listy - wbudowana nie powtórzy błędów z vehicles sieci
+	file.Duration, file.Vehicles = f.Duration, defaultScenarioFile().Vehicles
+	scenario, err := file.scenarioFile.build()
+	if err != nil {
+		errs = append(errs, prefixErrors(err, field))
+	}
+	if len(errs) > 0 {
+		return StationConfig{}, errors.Join(errs...)
+	}
+	return StationConfig{Name: file.Name, Distance: file.Distance, Scenario: scenario}, nil
+}
+
+// prefixErrors poprzedza ścieżki pól w błędach walidacji (wiersze
+// "  pole: opis") ścieżką stacji
+func prefixErrors(err error, prefix string) error {
+	lines := strings.Split(err.Error(), "\n")
+	for i, line := range lines {
+		lines[i] = "  " + prefix + "." + strings.TrimPrefix(line, "  ")
+	}
+	return errors.New(strings.Join(lines, "\n"))
+}
+
+// runNetwork uruchamia sieć stacji z pliku path i wypisuje podsumowanie.
+// seed i duration różne od zera nadpisują wartości z pliku.
+func runNetwork(path string, seed int64, duration time.Duration, virtual bool, mode StopMode) error {
+	ns, err := LoadNetwork(path)
+	if err != nil {
+		return err
+	}
+	if seed != 0 {
+		ns.Seed = seed
+	}
+	if ns.Seed == 0 {
+		ns.Seed = time.Now().UnixNano()
+	}
+	if duration > 0 {
+		ns.Duration = duration
+	}
+
+	var clock Clock = RealClock{}
+	if virtual {
+		clock = NewVirtualClock(simulationStart)
+	}
+	network := NewNetwork(clock, ns)
+
+	ctx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+
+	network.Start(ctx)
+	if virtual {
+		clock.Sleep(ns.Duration)
+	} else {
+		fmt.Printf("Sieć %d stacji uruchomiona. Naciśnij Ctrl+C aby zakończyć.\n", len(network.Stations))
+		select {
+		case <-ctx.Done():
+		case <-time.After(ns.Duration):
+		}
+	}
+	stopSignals()
+	network.Stop(mode)
+
+	network.PrintSummary()
+	fmt.Printf("\nSymulacja zakończona (ziarno: %d).\n", ns.Seed)
+	return nil
+}
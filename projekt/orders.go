@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// OrderMode określa, ile paliwa zamawia kierowca
+type OrderMode int
+
+const (
+	OrderLiters OrderMode = iota // stała liczba litrów (FuelAmount)
+	OrderFull                    // do pełna: do pojemności baku
+	OrderAmount                  // za stałą kwotę (MoneyLimit), np. 100 PLN
+)
+
+func (m OrderMode) String() string {
+	switch m {
+	case OrderLiters:
+		return "Litry"
+	case OrderFull:
+		return "Do pełna"
+	case OrderAmount:
+		return "Za kwotę"
+	default:
+		return "Nieznany"
+	}
+}
+
+// Wszystkie tryby zamówień w kolejności wyświetlania
+var allOrderModes = []OrderMode{OrderLiters, OrderFull, OrderAmount}
+
+// Nazwy trybów zamówień używane w scenariuszach, API i dzienniku
+var orderModeNames = map[string]OrderMode{
+	"liters": OrderLiters,
+	"full":   OrderFull,
+	"amount": OrderAmount,
+}
+
+// orderShare to udział trybu zamówienia wśród kierowców
+type orderShare struct {
+	mode  OrderMode
+	share float64
+}
+
+// OrderStatistics przechowuje sprzedaż jednego trybu zamówień
+type OrderStatistics struct {
+	Served  int
+	Liters  float64
+	Revenue float64
+}
+
+// freeSpace zwraca wolne miejsce w baku pojazdu przy przyjeździe
+// (+Inf - bak nieznany)
+func (v *Vehicle) freeSpace() float64 {
+	if v.TankCapacity == 0 {
+		return math.Inf(1)
+	}
+	return max(v.TankCapacity-v.TankLevel, 0)
+}
+
+// orderLiters zwraca paliwo, które kierowca zamówi przy cenie price: do
+// pełna - wolne miejsce w baku, za kwotę - tyle, ile kosztuje kwota
+// (nie więcej, niż zmieści bak), a w pozostałych przypadkach FuelAmount
+func (v *Vehicle) orderLiters(price float64) float64 {
+	switch v.Order {
+	case OrderFull:
+		return v.freeSpace()
+	case OrderAmount:
+		return min(v.MoneyLimit/price, v.freeSpace())
+	default:
+		return v.FuelAmount
+	}
+}
+
+// prepareOrder ustala po przyjeździe ilość paliwa zamówionego do pełna
+// albo za kwotę według bieżącej ceny
+func (gs *GasStation) prepareOrder(vehicle *Vehicle) {
+	if vehicle.Order != OrderLiters {
+		vehicle.FuelAmount = vehicle.orderLiters(gs.Pricing.Price(vehicle.FuelType))
+	}
+}
+
+// remainingOrder zwraca paliwo, które pojazd ma jeszcze zatankować przy
+// dystrybutorze. Zamówienie za kwotę jest przeliczane według bieżącej
+// ceny, bo cena mogła się zmienić, gdy kierowca czekał w kolejce.
+// Wywoływana z zablokowanym mutexem dystrybutora.
+func (gs *GasStation) remainingOrder(vehicle *Vehicle) float64 {
+	if vehicle.Order == OrderAmount {
+		price := gs.Pricing.Price(vehicle.FuelType)
+		remaining := min((vehicle.MoneyLimit-vehicle.billed)/price, vehicle.freeSpace()-vehicle.dispensed)
+		vehicle.FuelAmount = vehicle.dispensed + max(remaining, 0)
+	}
+	return vehicle.FuelAmount - vehicle.dispensed
+}
+
+// ordersEnabled sprawdza, czy kierowcy zamawiają paliwo inaczej niż
+// w litrach. Wywoływana z zablokowanym mutexem statystyk.
+func (gs *GasStation) ordersEnabled() bool {
+	for _, p := range gs.Scenario.Vehicles {
+		if len(p.Orders) > 0 {
+			return true
+		}
+	}
+	return gs.Stats.Orders[OrderFull].Served > 0 || gs.Stats.Orders[OrderAmount].Served > 0
+}
+
+// printOrders wypisuje w podsumowaniu sprzedaż według trybu zamówień.
+// Wywoływana z zablokowanym mutexem statystyk.
+func printOrders(gs *GasStation) {
+	fmt.Println("\nZamówienia:")
+	for _, m := range allOrderModes {
+		st := gs.Stats.Orders[m]
+		if st.Served == 0 {
+			fmt.Printf("  %-9s obsłużono: %5d\n", m, 0)
+			continue
+		}
+		fmt.Printf("  %-9s obsłużono: %5d, wydano: %10.1f L (średnio %5.1f L), przychód: %11.2f PLN (średnio %7.2f PLN)\n",
+			m, st.Served, st.Liters, st.Liters/float64(st.Served), st.Revenue, st.Revenue/float64(st.Served))
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOrderLiters(t *testing.T) {
+	tests := []struct {
+		name    string
+		vehicle Vehicle
+		want    float64
+	}{
+		{"litry", Vehicle{Order: OrderLiters, FuelAmount: 30, TankCapacity: 50, TankLevel: 45}, 30},
+		{"do pełna", Vehicle{Order: OrderFull, TankCapacity: 50, TankLevel: 8}, 42},
+		{"do pełna, bak pełny", Vehicle{Order: OrderFull, TankCapacity: 50, TankLevel: 50}, 0},
+		{"za kwotę", Vehicle{Order: OrderAmount, MoneyLimit: 100}, 20},
+		{"za kwotę, mały bak", Vehicle{Order: OrderAmount, MoneyLimit: 100, TankCapacity: 50, TankLevel: 40}, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.vehicle.orderLiters(5); got != tt.want {
+				t.Errorf("orderLiters(5) = %v, oczekiwano %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// newOrderStation zwraca stację wbudowanego scenariusza na zegarze
+// wirtualnym, bez uruchomionych goroutines, z ceną benzyny 95 równą 5 PLN
+func newOrderStation() (*GasStation, *VirtualClock) {
+	clock := NewVirtualClock(simulationStart)
+	gs := NewGasStation(clock, DefaultScenario())
+	gs.Headless = true
+	gs.Pricing.SetBase(map[FuelType]float64{Gasoline95: 5})
+	return gs, clock
+}
+
+func TestRemainingOrderRepricesAmount(t *testing.T) {
+	gs, _ := newOrderStation()
+	vehicle := &Vehicle{FuelType: Gasoline95, Order: OrderAmount, MoneyLimit: 100}
+	gs.prepareOrder(vehicle)
+	if vehicle.FuelAmount != 20 {
+		t.Fatalf("zamówienie za 100 PLN po 5 PLN/L: %v L, oczekiwano 20", vehicle.FuelAmount)
+	}
+
+	// Awaria po 10 L (50 PLN), a w kolejce cena wzrosła do 10 PLN/L:
+	// za pozostałe 50 PLN kierowca dostaje 5 L
+	vehicle.dispensed, vehicle.billed = 10, 50
+	gs.Pricing.SetBase(map[FuelType]float64{Gasoline95: 10})
+	if remaining := gs.remainingOrder(vehicle); remaining != 5 {
+		t.Errorf("pozostało %v L, oczekiwano 5", remaining)
+	}
+	if vehicle.FuelAmount != 15 {
+		t.Errorf("zamówienie %v L, oczekiwano 15", vehicle.FuelAmount)
+	}
+
+	liters := &Vehicle{FuelType: Gasoline95, Order: OrderLiters, FuelAmount: 30, dispensed: 10}
+	if remaining := gs.remainingOrder(liters); remaining != 20 {
+		t.Errorf("zamówienie w litrach: pozostało %v L, oczekiwano 20", remaining)
+	}
+}
+
+func TestDispenseBillsEachTick(t *testing.T) {
+	gs, clock := newOrderStation()
+	pump := gs.Pumps[0] // 10 L/s: 20 L w czterech taktach po 0,5 s
+	vehicle := &Vehicle{FuelType: Gasoline95, Order: OrderLiters, FuelAmount: 20}
+
+	// Cena rośnie w trakcie drugiego taktu, więc pierwszy takt (5 L)
+	// kosztuje po 5 PLN/L, a pozostałe 15 L po 6 PLN/L
+	clock.AfterFunc(750*time.Millisecond, func() {
+		gs.Pricing.SetBase(map[FuelType]float64{Gasoline95: 6})
+	})
+	start := clock.Now()
+	delivered, outcome := gs.dispense(pump, vehicle, 20)
+
+	if delivered != 20 || outcome != refuelCompleted {
+		t.Fatalf("dispense = (%v, %v), oczekiwano (20, refuelCompleted)", delivered, outcome)
+	}
+	if elapsed := clock.Since(start); elapsed != 2*time.Second {
+		t.Errorf("tankowanie trwało %v, oczekiwano 2s", elapsed)
+	}
+	if want := 5*5 + 15*6.0; math.Abs(vehicle.billed-want) > 1e-9 {
+		t.Errorf("kwota %v, oczekiwano %v", vehicle.billed, want)
+	}
+	if pump.CurrentLiters != 20 || pump.CurrentCost != vehicle.billed {
+		t.Errorf("licznik dystrybutora %v L / %v PLN, oczekiwano 20 L / %v PLN",
+			pump.CurrentLiters, pump.CurrentCost, vehicle.billed)
+	}
+}
+
+func TestDispenseStopsAtMoneyLimit(t *testing.T) {
+	gs, clock := newOrderStation()
+	pump := gs.Pumps[0]
+	vehicle := &Vehicle{FuelType: Gasoline95, Order: OrderLiters, FuelAmount: 20, MoneyLimit: 37}
+
+	start := clock.Now()
+	delivered, outcome := gs.dispense(pump, vehicle, 20)
+
+	// 37 PLN to 7,4 L: pełny takt 5 L i część drugiego
+	if outcome != refuelLimit || math.Abs(delivered-7.4) > 1e-9 {
+		t.Fatalf("dispense = (%v, %v), oczekiwano (7.4, refuelLimit)", delivered, outcome)
+	}
+	if vehicle.billed != 37 {
+		t.Errorf("kwota %v, oczekiwano dokładnie limitu 37", vehicle.billed)
+	}
+	if elapsed := clock.Since(start); elapsed != 740*time.Millisecond {
+		t.Errorf("tankowanie trwało %v, oczekiwano 740ms", elapsed)
+	}
+}
+
+func TestBuildRejectsTankAboveTankCapacity(t *testing.T) {
+	f := defaultScenarioFile()
+	f.Tanks = tankFile{Capacity: 100, RefillLevel: 20}
+	f.Vehicles[0].Tank = rangeFile{40, 150}
+	f.Vehicles[0].TankLevel = rangeFile{0, 0.5}
+
+	_, err := f.build()
+	if err == nil || !strings.Contains(err.Error(), "vehicles[0].tank.max: przekracza pojemność zbiornika") {
+		t.Errorf("bak większy od zbiornika przeszedł walidację: %v", err)
+	}
+}
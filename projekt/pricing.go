@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// PricingPolicy opisuje strategię cenową stacji i jej parametry
+type PricingPolicy struct {
+	Strategy string        // "fixed", "time_of_day", "demand" albo "stock"
+	Interval time.Duration // co ile przeliczane są ceny
+	// HourlyMultipliers to mnożnik ceny w każdej godzinie doby (time_of_day)
+	HourlyMultipliers [24]float64
+	// SurgePerVehicle to podwyżka za każdy pojazd czekający na dystrybutor
+	// danego paliwa, ograniczona do MaxSurge (demand)
+	SurgePerVehicle float64
+	MaxSurge        float64
+	// StockPremium to podwyżka przy pustym zbiorniku, rosnąca liniowo
+	// w miarę opróżniania (stock)
+	StockPremium float64
+}
+
+// Strategie cenowe w kolejności porównania
+var pricingStrategies = []string{"fixed", "time_of_day", "demand", "stock"}
+
+// market to stan rynku jednego paliwa, na który reagują strategie
+type market struct {
+	now          time.Time
+	queuePerPump float64 // czekające pojazdy na dystrybutor sprzedający paliwo
+	stockLevel   float64 // ułamek pojemności zbiornika
+}
+
+// multiplier zwraca mnożnik ceny bazowej według strategii
+func (p PricingPolicy) multiplier(m market) float64 {
+	switch p.Strategy {
+	case "time_of_day":
+		return p.HourlyMultipliers[m.now.Hour()]
+	case "demand":
+		return 1 + min(p.SurgePerVehicle*m.queuePerPump, p.MaxSurge)
+	case "stock":
+		return 1 + p.StockPremium*(1-m.stockLevel)
+	default:
+		return 1
+	}
+}
+
+// Pricing ustala ceny paliwa według strategii. Ceny czytają dystrybutory,
+// generatory pojazdów i API, a zmieniają przeliczenie strategii i API,
+// więc chroni je RWMutex.
+type Pricing struct {
+	Policy  PricingPolicy
+	base    map[FuelType]float64 // ceny bazowe: ze scenariusza albo z API
+	factor  map[FuelType]float64 // ostatni mnożnik strategii
+	current map[FuelType]float64
+	low     map[FuelType]float64 // najniższa i najwyższa cena w symulacji
+	high    map[FuelType]float64
+	mutex   sync.RWMutex
+}
+
+// NewPricing tworzy cennik z cenami bazowymi base
+func NewPricing(policy PricingPolicy, base map[FuelType]float64) *Pricing {
+	p := &Pricing{
+		Policy:  policy,
+		base:    maps.Clone(base),
+		factor:  make(map[FuelType]float64),
+		current: maps.Clone(base),
+		low:     maps.Clone(base),
+		high:    maps.Clone(base),
+	}
+	for ft := range base {
+		p.factor[ft] = 1
+	}
+	return p
+}
+
+// Price zwraca bieżącą cenę paliwa (za litr)
+func (p *Pricing) Price(fuelType FuelType) float64 {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.current[fuelType]
+}
+
+// Prices zwraca kopię bieżących cen
+func (p *Pricing) Prices() map[FuelType]float64 {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return maps.Clone(p.current)
+}
+
+// Range zwraca najniższą i najwyższą cenę paliwa w czasie symulacji
+func (p *Pricing) Range(fuelType FuelType) (low, high float64) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.low[fuelType], p.high[fuelType]
+}
+
+// SetBase zmienia ceny bazowe podanych paliw; bieżące ceny uwzględniają
+// od razu ostatni mnożnik strategii. Zwraca paliwa, których cena się
+// zmieniła.
+func (p *Pricing) SetBase(prices map[FuelType]float64) []FuelType {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for ft, price := range prices {
+		p.base[ft] = price
+		if _, ok := p.factor[ft]; !ok {
+			p.factor[ft] = 1
+		}
+	}
+	return p.apply()
+}
+
+// update przelicza mnożniki strategii dla stanu rynku każdego paliwa.
+// Zwraca paliwa, których cena się zmieniła.
+func (p *Pricing) update(markets map[FuelType]market) []FuelType {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for ft, m := range markets {
+		p.factor[ft] = p.Policy.multiplier(m)
+	}
+	return p.apply()
+}
+
+// apply wylicza bieżące ceny (zaokrąglone do grosza) z cen bazowych
+// i mnożników. Wywoływana z zablokowanym mutexem.
+func (p *Pricing) apply() []FuelType {
+	var changed []FuelType
+	for _, ft := range allFuelTypes {
+		base, ok := p.base[ft]
+		if !ok {
+			continue
+		}
+		price := math.Round(base*p.factor[ft]*100) / 100
+		if old, ok := p.current[ft]; ok && old == price {
+			continue
+		}
+		p.current[ft] = price
+		if low, ok := p.low[ft]; !ok || price < low {
+			p.low[ft] = price
+		}
+		p.high[ft] = max(p.high[ft], price)
+		changed = append(changed, ft)
+	}
+	return changed
+}
+
+// startPricing przelicza ceny na starcie i uruchamia ich okresowe
+// przeliczanie. Stałe ceny nie potrzebują goroutine.
+func (gs *GasStation) startPricing() {
+	if gs.Pricing.Policy.Strategy == "fixed" {
+		return
+	}
+	gs.updatePrices()
+	gs.goTracked(func() {
+		for gs.pause(gs.Pricing.Policy.Interval) {
+			gs.updatePrices()
+		}
+	})
+}
+
+// updatePrices przelicza ceny według bieżącego stanu stacji i publikuje
+// zmiany. Dystrybutory rozliczają każdy takt tankowania według bieżącej
+// ceny, więc nowa cena obowiązuje od razu.
+func (gs *GasStation) updatePrices() {
+	now := gs.Clock.Now()
+	markets := make(map[FuelType]market)
+	for _, ft := range allFuelTypes {
+		markets[ft] = market{now: now, queuePerPump: gs.queuePerPump(ft), stockLevel: gs.Tanks[ft].fill()}
+	}
+	gs.publishPrices(gs.Pricing.update(markets))
+}
+
+// publishPrices publikuje zdarzenia zmiany cen podanych paliw
+func (gs *GasStation) publishPrices(changed []FuelType) {
+	for _, ft := range changed {
+		gs.Events.Publish(Event{Kind: EventPriceChanged, Fuel: fuelTypeKey(ft), Price: gs.Pricing.Price(ft)})
+	}
+}
+
+// queuePerPump zwraca liczbę pojazdów czekających na paliwo na każdy
+// dystrybutor, który je sprzedaje
+func (gs *GasStation) queuePerPump(fuelType FuelType) float64 {
+	pumps := 0
+	for _, pump := range gs.Pumps {
+		if slices.Contains(pump.FuelTypes, fuelType) {
+			pumps++
+		}
+	}
+	if pumps == 0 {
+		return 0
+	}
+	return float64(gs.Queue.LineLen(fuelType)) / float64(pumps)
+}
+
+// acceptsPrice dopasowuje ilość paliwa kierowcy wrażliwego na cenę do
+// stosunku bieżącej ceny do ceny ze scenariusza (elastyczność cenowa).
+// Zwraca false, gdy cena przekracza jego limit i kierowca odjeżdża.
+func (gs *GasStation) acceptsPrice(vehicle *Vehicle) bool {
+	if vehicle.PriceElasticity == 0 && vehicle.PriceLimit == 0 {
+		return true
+	}
+	reference := gs.Scenario.Prices[vehicle.FuelType]
+	if reference == 0 {
+		return true
+	}
+	ratio := gs.Pricing.Price(vehicle.FuelType) / reference
+	if vehicle.PriceLimit > 0 && ratio > 1+vehicle.PriceLimit {
+		return false
+	}
+	// Zamówienie do pełna albo za kwotę nie zależy od elastyczności
+	if vehicle.Order == OrderLiters {
+		vehicle.FuelAmount *= math.Pow(ratio, -vehicle.PriceElasticity)
+	}
+	return true
+}
+
+// pricingEnabled sprawdza, czy ceny się zmieniają albo wpływają na
+// kierowców
+func (gs *GasStation) pricingEnabled() bool {
+	for _, p := range gs.Scenario.Vehicles {
+		if p.PriceElasticity > 0 || p.PriceLimit > 0 {
+			return true
+		}
+	}
+	return gs.Pricing.Policy.Strategy != "fixed"
+}
+
+// printPricing wypisuje w podsumowaniu strategię cenową, zakres cen
+// i średnią cenę sprzedaży każdego paliwa. Wywoływana z zablokowanym
+// mutexem statystyk.
+func printPricing(gs *GasStation) {
+	fmt.Printf("\nCeny (strategia: %s):\n", gs.Pricing.Policy.Strategy)
+	for _, ft := range allFuelTypes {
+		reference, ok := gs.Scenario.Prices[ft]
+		if !ok {
+			continue
+		}
+		low, high := gs.Pricing.Range(ft)
+		fs := gs.Stats.Fuel[ft]
+		fmt.Printf("  %-10s bazowa %5.2f, zakres %5.2f-%5.2f, średnia sprzedaży %5.2f PLN/L\n",
+			ft, reference, low, high, share(fs.Revenue, fs.Dispensed))
+	}
+	fmt.Printf("  Odjechali przez cenę: %d (%.2f PLN)\n", gs.Stats.PriceBalked, gs.Stats.PriceLostRevenue)
+}
+
+// pricingResult to wynik jednego przebiegu porównania strategii
+type pricingResult struct {
+	strategy               string
+	vehicles, served       int
+	priceBalked, queueLost int
+	dispensed, revenue     float64
+	averageWait            time.Duration
+}
+
+// comparePricing uruchamia scenariusz w czasie wirtualnym raz dla każdej
+// strategii cenowej i wypisuje porównanie przychodów. Strumień przyjazdów
+// zależy tylko od ziarna, więc każda strategia obsługuje tych samych
+// kierowców.
+func comparePricing(scenario *Scenario) {
+	var results []pricingResult
+	for _, strategy := range pricingStrategies {
+		s := *scenario
+		s.Pricing.Strategy = strategy
+
+		clock := NewVirtualClock(simulationStart)
+		station := NewGasStation(clock, &s)
+		station.Headless = true
+		station.Start(context.Background())
+		clock.Sleep(s.Duration)
+		station.Stop(StopDrain)
+
+		stats := station.Stats
+		stats.mutex.RLock()
+		results = append(results, pricingResult{
+			strategy:    strategy,
+			vehicles:    stats.TotalVehicles,
+			served:      stats.ServedVehicles,
+			priceBalked: stats.PriceBalked,
+			queueLost:   stats.BalkedVehicles + stats.RenegedVehicles,
+			dispensed:   stats.TotalFuelDispensed,
+			revenue:     stats.TotalRevenue,
+			averageWait: stats.AverageWaitTime,
+		})
+		stats.mutex.RUnlock()
+	}
+
+	fmt.Printf("\nPORÓWNANIE STRATEGII CENOWYCH (czas: %v, ziarno: %d)\n\n", scenario.Duration, scenario.Seed)
+	fmt.Println("  Strategia    Pojazdy  Obsłużone  Odjechali (cena)  Odjechali (kolejka)   Paliwo [L]  Przychód [PLN]  Śr. cena  Zmiana przychodu  Śr. oczekiwanie")
+	for _, r := range results {
+		fmt.Printf("  %-11s  %7d  %9d  %16d  %19d  %11.1f  %14.2f  %8.2f  %15.1f%%  %15v\n",
+			r.strategy, r.vehicles, r.served, r.priceBalked, r.queueLost, r.dispensed, r.revenue,
+			share(r.revenue, r.dispensed), 100*(share(r.revenue, results[0].revenue)-1),
+			r.averageWait.Round(10*time.Millisecond))
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import "time"
+
+// Priority to klasa priorytetu pojazdu w kolejce do dystrybutorów
+type Priority int
+
+const (
+	PriorityStandard  Priority = iota
+	PriorityFleet              // klienci flotowi i programu lojalnościowego
+	PriorityEmergency          // pojazdy uprzywilejowane: karetki, policja
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityStandard:
+		return "Klasa zwykła"
+	case PriorityFleet:
+		return "Klasa flotowa"
+	case PriorityEmergency:
+		return "Klasa alarmowa"
+	default:
+		return "Nieznana"
+	}
+}
+
+// Wszystkie klasy priorytetu w kolejności wyświetlania
+var allPriorities = []Priority{PriorityStandard, PriorityFleet, PriorityEmergency}
+
+// Nazwy klas priorytetu używane w scenariuszach i API
+var priorityNames = map[string]Priority{
+	"standard":  PriorityStandard,
+	"fleet":     PriorityFleet,
+	"emergency": PriorityEmergency,
+}
+
+// Poziomy klas priorytetu. Każde Scenario.PriorityAging czekania podnosi
+// pojazd o jeden poziom, więc np. zwykły pojazd czekający pięć takich
+// okresów wyprzedza świeżo przybyły pojazd uprzywilejowany - żadna klasa
+// nie czeka w nieskończoność.
+var priorityLevels = map[Priority]float64{
+	PriorityStandard:  0,
+	PriorityFleet:     1,
+	PriorityEmergency: 5,
+}
+
+// Domyślna klasa priorytetu według typu pojazdu; pozostałe są zwykłe
+var defaultPriority = map[VehicleType]Priority{Emergency: PriorityEmergency}
+
+// score zwraca bieżący priorytet pojazdu: poziom jego klasy powiększony
+// o jeden za każde aging czekania w kolejce
+func score(vehicle *Vehicle, now time.Time, aging time.Duration) float64 {
+	return priorityLevels[vehicle.Priority] + float64(now.Sub(vehicle.ArrivalTime))/float64(aging)
+}
+
+// ahead sprawdza, czy pojazd a ma pierwszeństwo przed b: najpierw pojazdy,
+// którym awaria przerwała tankowanie, potem wyższy bieżący priorytet,
+// a przy równym - wcześniejszy przyjazd
+func ahead(a, b *Vehicle, now time.Time, aging time.Duration) bool {
+	if a.interrupted != b.interrupted {
+		return a.interrupted
+	}
+	if sa, sb := score(a, now, aging), score(b, now, aging); sa != sb {
+		return sa > sb
+	}
+	return a.ArrivalTime.Before(b.ArrivalTime)
+}
+
+// usesPriorities sprawdza, czy obsłużono pojazdy spoza klasy zwykłej.
+// Wywoływana z zablokowanym mutexem statystyk.
+func (s *Statistics) usesPriorities() bool {
+	for _, p := range allPriorities {
+		if p != PriorityStandard && s.ByPriority[p].Wait.Count() > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// maxStandardWait przeprowadza scenariusz w czasie wirtualnym i zwraca
+// najdłuższe czekanie pojazdu klasy zwykłej do końca symulacji - wśród
+// obsłużonych i wciąż czekających w kolejce - oraz liczbę obsłużonych
+func maxStandardWait(t *testing.T, s Scenario) (time.Duration, int64) {
+	t.Helper()
+	clock := NewVirtualClock(simulationStart)
+	gs := NewGasStation(clock, &s)
+	gs.Headless = true
+	gs.Start(context.Background())
+	clock.Sleep(s.Duration)
+
+	// Zegar oddał sterowanie testowi, więc goroutines stacji stoją
+	now := clock.Now()
+	wait := &gs.Stats.ByPriority[PriorityStandard].Wait
+	longest, served := wait.Max(), wait.Count()
+	if arrival, ok := gs.Queue.OldestArrival(PriorityStandard); ok {
+		longest = max(longest, now.Sub(arrival))
+	}
+	gs.Stop(StopAbort)
+	return longest, served
+}
+
+// TestPriorityAgingPreventsStarvation uruchamia przesycony scenariusz
+// (kolejka stale pełna klientów flotowych) z aging i praktycznie bez
+// niego. Z aging ciężarówki klasy zwykłej czekają najwyżej kilka okresów
+// priority_aging, bez niego nie są obsługiwane wcale.
+func TestPriorityAgingPreventsStarvation(t *testing.T) {
+	s, err := LoadScenario("scenarios/saturated.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.PriorityAging != time.Minute {
+		t.Fatalf("priority_aging w saturated.json = %v, test zakłada 1m", s.PriorityAging)
+	}
+	bound := 10 * s.PriorityAging
+
+	longest, served := maxStandardWait(t, *s)
+	if served == 0 {
+		t.Fatal("z aging nie obsłużono żadnego pojazdu klasy zwykłej")
+	}
+	if longest > bound {
+		t.Errorf("z aging %v klasa zwykła czekała %v, więcej niż %v", s.PriorityAging, longest, bound)
+	}
+
+	noAging := *s
+	noAging.PriorityAging = 1000 * time.Hour
+	longest, served = maxStandardWait(t, noAging)
+	if longest <= bound {
+		t.Errorf("bez aging klasa zwykła czekała najwyżej %v (obsłużono %d) - test nie zależy od aging", longest, served)
+	}
+}
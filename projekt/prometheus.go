@@ -0,0 +1,264 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Granice przedziałów histogramu czasu oczekiwania w /metrics
+var waitTimeBuckets = []time.Duration{
+	500 * time.Millisecond,
+	time.Second,
+	2 * time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	2 * time.Minute,
+	5 * time.Minute,
+	10 * time.Minute,
+}
+
+// labelEscaper zapisuje wartość etykiety w formacie tekstowym Prometheusa:
+// zamienia tylko \\, " i znak nowej linii, a pozostałe znaki UTF-8 (np.
+// polskie litery w nazwach zmian) zostawia bez zmian. %q nie nadaje się
+// do tego, bo wstawia escape'y Go (\u0105, \t), których format nie zna.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// promWriter wypisuje metryki w formacie tekstowym Prometheusa
+type promWriter struct {
+	w io.Writer
+}
+
+// family wypisuje opis metryki (HELP i TYPE)
+func (p promWriter) family(name, kind, help string) {
+	fmt.Fprintf(p.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// sample wypisuje wartość metryki; labels to pary nazwa, wartość
+func (p promWriter) sample(name string, value float64, labels ...string) {
+	fmt.Fprint(p.w, name)
+	if len(labels) > 0 {
+		fmt.Fprint(p.w, "{")
+		for i := 0; i < len(labels); i += 2 {
+			if i > 0 {
+				fmt.Fprint(p.w, ",")
+			}
+			fmt.Fprintf(p.w, "%s=\"%s\"", labels[i], labelEscaper.Replace(labels[i+1]))
+		}
+		fmt.Fprint(p.w, "}")
+	}
+	fmt.Fprintf(p.w, " %s\n", strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// handleMetrics udostępnia statystyki stacji dla Prometheusa
+func (gs *GasStation) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	p := promWriter{w}
+
+	// Stan bieżący: kolejki, dystrybutory, zbiorniki
+	p.family("gas_station_queue_length", "gauge", "Pojazdy czekające w kolejce danego paliwa.")
+	for _, ft := range allFuelTypes {
+		p.sample("gas_station_queue_length", float64(gs.Queue.LineLen(ft)), "fuel", fuelTypeKey(ft))
+	}
+
+	type pumpState struct {
+		busy, open         bool
+		served, failures   int
+		dispensed          float64
+		current, cost      float64 // liczniki bieżącego tankowania
+		busyTime, downTime time.Duration
+		id, fuelLabels     string
+	}
+	var pumps []pumpState
+	busyPumps := 0
+	now := gs.Clock.Now()
+	for _, pump := range gs.Pumps {
+		downTime := pump.Downtime(now)
+		pump.mutex.Lock()
+		pumps = append(pumps, pumpState{
+			busy:       pump.IsOccupied,
+			open:       pump.inService(),
+			served:     pump.Served,
+			failures:   pump.Failures,
+			dispensed:  pump.Dispensed,
+			current:    pump.CurrentLiters,
+			cost:       pump.CurrentCost,
+			busyTime:   pump.BusyTime,
+			downTime:   downTime,
+			id:         strconv.Itoa(pump.ID),
+			fuelLabels: fuelKeys(pump.FuelTypes),
+		})
+		if pump.IsOccupied {
+			busyPumps++
+		}
+		pump.mutex.Unlock()
+	}
+	p.family("gas_station_pumps_busy", "gauge", "Liczba zajętych dystrybutorów.")
+	p.sample("gas_station_pumps_busy", float64(busyPumps))
+	p.family("gas_station_pump_open", "gauge", "1, jeśli dystrybutor przyjmuje pojazdy (nie jest zamknięty, zepsuty, w przeglądzie ani poza zmianą).")
+	for _, ps := range pumps {
+		p.sample("gas_station_pump_open", boolValue(ps.open), "pump", ps.id, "fuels", ps.fuelLabels)
+	}
+	p.family("gas_station_pump_served_total", "counter", "Pojazdy obsłużone na dystrybutorze.")
+	for _, ps := range pumps {
+		p.sample("gas_station_pump_served_total", float64(ps.served), "pump", ps.id)
+	}
+	p.family("gas_station_pump_dispensed_liters_total", "counter", "Paliwo wydane przez dystrybutor.")
+	for _, ps := range pumps {
+		p.sample("gas_station_pump_dispensed_liters_total", ps.dispensed, "pump", ps.id)
+	}
+	p.family("gas_station_pump_current_liters", "gauge", "Paliwo zatankowane dotąd przez pojazd przy dystrybutorze.")
+	for _, ps := range pumps {
+		p.sample("gas_station_pump_current_liters", ps.current, "pump", ps.id)
+	}
+	p.family("gas_station_pump_current_cost_pln", "gauge", "Kwota za paliwo zatankowane dotąd przez pojazd przy dystrybutorze.")
+	for _, ps := range pumps {
+		p.sample("gas_station_pump_current_cost_pln", ps.cost, "pump", ps.id)
+	}
+	p.family("gas_station_pump_busy_seconds_total", "counter", "Łączny czas zajętości dystrybutora (zakończone obsługi).")
+	for _, ps := range pumps {
+		p.sample("gas_station_pump_busy_seconds_total", ps.busyTime.Seconds(), "pump", ps.id)
+	}
+	p.family("gas_station_pump_failures_total", "counter", "Awarie dystrybutora.")
+	for _, ps := range pumps {
+		p.sample("gas_station_pump_failures_total", float64(ps.failures), "pump", ps.id)
+	}
+	p.family("gas_station_pump_down_seconds_total", "counter", "Łączny czas awarii i przeglądów dystrybutora.")
+	for _, ps := range pumps {
+		p.sample("gas_station_pump_down_seconds_total", ps.downTime.Seconds(), "pump", ps.id)
+	}
+
+	schedule := gs.Scenario.Schedule
+	if schedule.enabled() {
+		p.family("gas_station_open", "gauge", "1, jeśli stacja jest w godzinach otwarcia.")
+		p.sample("gas_station_open", boolValue(gs.isOpen()))
+		p.family("gas_station_cashiers_on_duty", "gauge", "Kasy obsadzone na bieżącej zmianie.")
+		p.sample("gas_station_cashiers_on_duty", float64(gs.Checkout.OnDuty()))
+	}
+
+	p.family("gas_station_price_pln", "gauge", "Bieżąca cena paliwa za litr.")
+	prices := gs.Pricing.Prices()
+	for _, ft := range allFuelTypes {
+		if price, ok := prices[ft]; ok {
+			p.sample("gas_station_price_pln", price, "fuel", fuelTypeKey(ft))
+		}
+	}
+
+	p.family("gas_station_tank_level_liters", "gauge", "Poziom paliwa w zbiorniku.")
+	for _, ft := range allFuelTypes {
+		tank := gs.Tanks[ft]
+		tank.mutex.Lock()
+		level := tank.Level
+		tank.mutex.Unlock()
+		p.sample("gas_station_tank_level_liters", level, "fuel", fuelTypeKey(ft))
+	}
+
+	// Liczniki ze statystyk
+	gs.Stats.mutex.RLock()
+	defer gs.Stats.mutex.RUnlock()
+
+	p.family("gas_station_vehicles_total", "counter", "Pojazdy, które przyjechały na stację.")
+	p.sample("gas_station_vehicles_total", float64(gs.Stats.TotalVehicles))
+	p.family("gas_station_vehicles_served_total", "counter", "Pojazdy obsłużone do końca (po zapłacie).")
+	p.sample("gas_station_vehicles_served_total", float64(gs.Stats.ServedVehicles))
+	p.family("gas_station_vehicles_lost_total", "counter", "Pojazdy, które odjechały bez obsługi, według powodu.")
+	p.sample("gas_station_vehicles_lost_total", float64(gs.Stats.BalkedVehicles), "reason", LeftBalked)
+	p.sample("gas_station_vehicles_lost_total", float64(gs.Stats.RenegedVehicles), "reason", LeftReneged)
+	p.sample("gas_station_vehicles_lost_total", float64(gs.Stats.TurnedAway), "reason", LeftTurnedAway)
+	lostSales := 0
+	for _, ft := range allFuelTypes {
+		lostSales += gs.Stats.Fuel[ft].LostSales
+	}
+	p.sample("gas_station_vehicles_lost_total", float64(lostSales), "reason", LeftStockOut)
+	p.sample("gas_station_vehicles_lost_total", float64(gs.Stats.PriceBalked), "reason", LeftPrice)
+	p.sample("gas_station_vehicles_lost_total", float64(gs.Stats.ClosedVehicles), "reason", LeftClosed)
+
+	p.family("gas_station_refuels_interrupted_total", "counter", "Tankowania przerwane awarią dystrybutora.")
+	p.sample("gas_station_refuels_interrupted_total", float64(gs.Stats.InterruptedRefuels))
+	p.family("gas_station_refuels_stopped_total", "counter", "Tankowania zakończone przed wydaniem zamówionego paliwa.")
+	p.sample("gas_station_refuels_stopped_total", float64(gs.Stats.LimitedRefuels), "reason", StoppedMoneyLimit)
+	p.sample("gas_station_refuels_stopped_total", float64(gs.Stats.AbortedRefuels), "reason", StoppedShutdown)
+
+	p.family("gas_station_fuel_dispensed_liters_total", "counter", "Wydane paliwo.")
+	for _, ft := range allFuelTypes {
+		p.sample("gas_station_fuel_dispensed_liters_total", gs.Stats.Fuel[ft].Dispensed, "fuel", fuelTypeKey(ft))
+	}
+	p.family("gas_station_stock_outs_total", "counter", "Braki paliwa w zbiorniku.")
+	for _, ft := range allFuelTypes {
+		p.sample("gas_station_stock_outs_total", float64(gs.Stats.Fuel[ft].StockOuts), "fuel", fuelTypeKey(ft))
+	}
+	p.family("gas_station_revenue_pln_total", "counter", "Przychód ze sprzedaży paliwa.")
+	p.sample("gas_station_revenue_pln_total", gs.Stats.TotalRevenue)
+	p.family("gas_station_lost_revenue_pln_total", "counter", "Przychód utracony przez kolejkę.")
+	p.sample("gas_station_lost_revenue_pln_total", gs.Stats.LostRevenue)
+
+	if gs.ordersEnabled() {
+		p.family("gas_station_orders_served_total", "counter", "Pojazdy obsłużone według trybu zamówienia.")
+		for _, m := range allOrderModes {
+			p.sample("gas_station_orders_served_total", float64(gs.Stats.Orders[m].Served), "order", nameOf(orderModeNames, m))
+		}
+		p.family("gas_station_orders_revenue_pln_total", "counter", "Przychód ze sprzedaży paliwa według trybu zamówienia.")
+		for _, m := range allOrderModes {
+			p.sample("gas_station_orders_revenue_pln_total", gs.Stats.Orders[m].Revenue, "order", nameOf(orderModeNames, m))
+		}
+	}
+
+	if len(schedule.Shifts) > 0 {
+		p.family("gas_station_shift_vehicles_total", "counter", "Pojazdy według zmiany, na którą przyjechały, i wyniku obsługi.")
+		for i, shift := range schedule.Shifts {
+			st := gs.Stats.Shifts[i]
+			p.sample("gas_station_shift_vehicles_total", float64(st.Served), "shift", shift.Name, "result", "served")
+			p.sample("gas_station_shift_vehicles_total", float64(st.Lost), "shift", shift.Name, "result", "lost")
+			p.sample("gas_station_shift_vehicles_total", float64(st.Closed), "shift", shift.Name, "result", LeftClosed)
+		}
+		p.family("gas_station_shift_revenue_pln_total", "counter", "Przychód ze sprzedaży paliwa według zmiany.")
+		for i, shift := range schedule.Shifts {
+			p.sample("gas_station_shift_revenue_pln_total", gs.Stats.Shifts[i].Revenue, "shift", shift.Name)
+		}
+	}
+
+	if gs.servicesEnabled() {
+		stages := []struct {
+			name  string
+			stats *StageStatistics
+		}{{"shop", gs.Stats.Shop}, {"wash", gs.Stats.Wash}}
+		p.family("gas_station_stage_served_total", "counter", "Klienci obsłużeni w sklepie i myjni.")
+		for _, s := range stages {
+			p.sample("gas_station_stage_served_total", float64(s.stats.Served), "stage", s.name)
+		}
+		p.family("gas_station_stage_skipped_total", "counter", "Klienci, którzy zrezygnowali z etapu przez kolejkę.")
+		for _, s := range stages {
+			p.sample("gas_station_stage_skipped_total", float64(s.stats.Skipped), "stage", s.name)
+		}
+		p.family("gas_station_stage_revenue_pln_total", "counter", "Przychód dodatkowy ze sklepu i myjni.")
+		for _, s := range stages {
+			p.sample("gas_station_stage_revenue_pln_total", s.stats.Revenue, "stage", s.name)
+		}
+	}
+
+	p.family("gas_station_wait_seconds", "histogram", "Czas oczekiwania obsłużonych pojazdów na dystrybutor.")
+	for _, vt := range allVehicleTypes {
+		h := &gs.Stats.ByVehicle[vt].Wait
+		vehicleType := nameOf(vehicleTypeNames, vt)
+		for _, le := range waitTimeBuckets {
+			p.sample("gas_station_wait_seconds_bucket", float64(h.CountAtMost(le)),
+				"vehicle_type", vehicleType, "le", strconv.FormatFloat(le.Seconds(), 'g', -1, 64))
+		}
+		p.sample("gas_station_wait_seconds_bucket", float64(h.Count()), "vehicle_type", vehicleType, "le", "+Inf")
+		p.sample("gas_station_wait_seconds_sum", h.Sum().Seconds(), "vehicle_type", vehicleType)
+		p.sample("gas_station_wait_seconds_count", float64(h.Count()), "vehicle_type", vehicleType)
+	}
+}
+
+// boolValue zamienia wartość logiczną na 0 albo 1
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestPromSampleEscapesLabels(t *testing.T) {
+	tests := []struct {
+		value, want string
+	}{
+		{"popołudniowa", `x{shift="popołudniowa"} 1`},
+		{`zmiana "A"`, `x{shift="zmiana \"A\""} 1`},
+		{`C:\kasa`, `x{shift="C:\\kasa"} 1`},
+		{"noc\nzmiana", `x{shift="noc\nzmiana"} 1`},
+		{"tab\tzmiana", "x{shift=\"tab\tzmiana\"} 1"}, // tabulator zostaje bez zmian
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		promWriter{&b}.sample("x", 1, "shift", tt.value)
+		if got := strings.TrimSuffix(b.String(), "\n"); got != tt.want {
+			t.Errorf("etykieta %q: %s, oczekiwano %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+// promLine to wiersz próbki w formacie tekstowym Prometheusa: nazwa,
+// opcjonalne etykiety z wartościami w cudzysłowach (dozwolone escape'y
+// to tylko \\, \" i \n) oraz wartość
+var promLine = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)` +
+	`(\{[a-zA-Z_][a-zA-Z0-9_]*="(?:[^"\\\n]|\\[\\"n])*"(?:,[a-zA-Z_][a-zA-Z0-9_]*="(?:[^"\\\n]|\\[\\"n])*")*\})?` +
+	` (\S+)$`)
+
+func TestMetricsFormat(t *testing.T) {
+	s, err := LoadScenario("scenarios/shifts.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Schedule.Shifts[0].Name = `ranna "A"\1` + "\n"
+	gs := NewGasStation(NewVirtualClock(simulationStart), s)
+	gs.Headless = true
+
+	w := request(t, NewAPI(gs), "GET", "/metrics", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /metrics: kod %d, oczekiwano 200", w.Code)
+	}
+
+	body := w.Body.String()
+	types := map[string]string{}
+	samples := 0
+	sc := bufio.NewScanner(strings.NewReader(body))
+	for sc.Scan() {
+		line := sc.Text()
+		if typ, ok := strings.CutPrefix(line, "# TYPE "); ok {
+			f := strings.Fields(typ)
+			types[f[0]] = f[1]
+			continue
+		}
+		if strings.HasPrefix(line, "# HELP ") {
+			continue
+		}
+		m := promLine.FindStringSubmatch(line)
+		if m == nil {
+			t.Errorf("niepoprawny wiersz: %s", line)
+			continue
+		}
+		if !described(types, m[1]) {
+			t.Errorf("próbka %s bez wcześniejszego opisu TYPE", m[1])
+		}
+		samples++
+	}
+	if samples == 0 {
+		t.Fatal("brak próbek w odpowiedzi")
+	}
+
+	for _, want := range []string{
+		`shift="ranna \"A\"\\1\n"`,
+		`shift="popołudniowa"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("brak etykiety %s", want)
+		}
+	}
+}
+
+// described sprawdza, czy próbka name należy do opisanej już rodziny;
+// próbki histogramu mają przyrostki _bucket, _sum i _count
+func described(types map[string]string, name string) bool {
+	if _, ok := types[name]; ok {
+		return true
+	}
+	for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+		if family, ok := strings.CutSuffix(name, suffix); ok && types[family] == "histogram" {
+			return true
+		}
+	}
+	return false
+}
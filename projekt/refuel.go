@@ -0,0 +1,104 @@
+package main
+
+import "time"
+
+// refuelTick to takt tankowania: co tyle czasu dystrybutor dolicza
+// wydane litry i kwotę do zapłaty
+const refuelTick = 500 * time.Millisecond
+
+// refuelOutcome mówi, jak zakończyło się wydawanie paliwa
+type refuelOutcome int
+
+const (
+	refuelCompleted refuelOutcome = iota // wydano całe paliwo
+	refuelBroken                         // awaria dystrybutora
+	refuelLimit                          // kwota doszła do limitu kierowcy
+	refuelAborted                        // zatrzymanie stacji w trybie abort
+)
+
+// Powody zakończenia tankowania przed wydaniem całego paliwa
+// (Event.Reason dla EventRefuelFinished)
+var refuelStopReasons = map[refuelOutcome]string{
+	refuelLimit:   StoppedMoneyLimit,
+	refuelAborted: StoppedShutdown,
+}
+
+// dispense wydaje pojazdowi liters litrów w taktach refuelTick. Po każdym
+// takcie dystrybutor pokazuje zatankowane litry i kwotę (CurrentLiters,
+// CurrentCost), a kwota rośnie według ceny z chwili taktu. Tankowanie
+// przerywa awaria dystrybutora, zatrzymanie stacji w trybie abort albo
+// limit kwoty kierowcy. Zwraca wydane litry i sposób zakończenia; kwota
+// za wszystkie zatankowane dotąd litry jest w vehicle.billed.
+func (gs *GasStation) dispense(pump *Pump, vehicle *Vehicle, liters float64) (float64, refuelOutcome) {
+	total := pump.RefuelTime(liters)
+	if total <= 0 {
+		gs.bill(pump, vehicle, liters, liters)
+		return liters, refuelCompleted
+	}
+
+	var spent time.Duration
+	delivered := 0.0
+	for spent < total {
+		if gs.aborting() {
+			return delivered, refuelAborted
+		}
+
+		// Litry po kolejnym takcie; kierowca z limitem kwoty zatrzymuje
+		// nalewanie, gdy kwota doszłaby do limitu
+		step := min(refuelTick, total-spent)
+		next := liters * float64(spent+step) / float64(total)
+		outcome := refuelCompleted
+		if vehicle.MoneyLimit > 0 {
+			price := gs.Pricing.Price(vehicle.FuelType)
+			if affordable := (vehicle.MoneyLimit - vehicle.billed) / price; delivered+affordable < next {
+				next = delivered + max(affordable, 0)
+				step = time.Duration(float64(total) * (next - delivered) / liters)
+				outcome = refuelLimit
+			}
+		}
+		if step <= 0 {
+			return delivered, outcome
+		}
+
+		start := gs.Clock.Now()
+		if !gs.refuel(pump, step) {
+			// Awaria w trakcie taktu: pojazd dostaje paliwo wydane do niej
+			done := min(float64(gs.Clock.Since(start))/float64(max(step, 1)), 1)
+			next = delivered + (next-delivered)*done
+			gs.bill(pump, vehicle, next-delivered, next)
+			return next, refuelBroken
+		}
+		spent += step
+		if outcome == refuelCompleted && spent >= total {
+			next = liters // bez błędów zaokrągleń na końcu
+		}
+		gs.bill(pump, vehicle, next-delivered, next)
+		delivered = next
+		if outcome == refuelLimit {
+			return delivered, refuelLimit
+		}
+	}
+	return delivered, refuelCompleted
+}
+
+// bill dolicza kierowcy liters litrów według bieżącej ceny i pokazuje
+// na dystrybutorze stan tankowania: delivered litrów w tym podejściu
+func (gs *GasStation) bill(pump *Pump, vehicle *Vehicle, liters, delivered float64) {
+	vehicle.billed += liters * gs.Pricing.Price(vehicle.FuelType)
+	if vehicle.MoneyLimit > 0 {
+		vehicle.billed = min(vehicle.billed, vehicle.MoneyLimit) // bez błędów zaokrągleń przy limicie
+	}
+
+	pump.mutex.Lock()
+	pump.CurrentLiters = vehicle.dispensed + delivered
+	pump.CurrentCost = vehicle.billed
+	pump.mutex.Unlock()
+}
+
+// aborting sprawdza, czy stację zatrzymano w trybie abort - wtedy
+// trwające tankowania kończą się przy najbliższym takcie
+func (gs *GasStation) aborting() bool {
+	gs.mutex.RLock()
+	defer gs.mutex.RUnlock()
+	return !gs.Running && gs.stopMode == StopAbort
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+)
+
+// QueueModel to model kolejki M/M/c: przyjazdy Poissona z intensywnością
+// Lambda, wykładniczy czas obsługi z intensywnością Mu na stanowisko
+// i Servers stanowisk (dystrybutorów)
+type QueueModel struct {
+	Lambda  float64 // pojazdy na sekundę
+	Mu      float64 // obsłużone pojazdy na sekundę na dystrybutor
+	Servers int
+}
+
+// NewQueueModel wyznacza parametry modelu ze scenariusza dla okna
+// od start przez Scenario.Duration. Czas obsługi to tankowanie i płatność,
+// bez czekania na kasę i cysternę.
+func NewQueueModel(s *Scenario, start time.Time) QueueModel {
+	var lambda, service float64
+	for _, p := range s.Vehicles {
+		rate := p.Arrival.MeanRate(start, s.Duration)
+		lambda += rate
+
+		fuel := (p.MinFuelAmount + p.MaxFuelAmount) / 2
+		refuel := fuel / s.flowRate(p.Type)
+		payment := paymentTimes[Card].Seconds()
+		for _, ps := range paymentShares[p.Type] {
+			payment += ps.share * (paymentTimes[ps.method] - paymentTimes[Card]).Seconds()
+		}
+		service += rate * (refuel + payment)
+	}
+
+	m := QueueModel{Lambda: lambda, Servers: len(s.Pumps)}
+	if lambda > 0 && service > 0 {
+		// Średni czas obsługi ważony intensywnością przyjazdów
+		m.Mu = lambda / service
+	}
+	return m
+}
+
+// flowRate zwraca średnią wydajność dystrybutorów, przy których mieści się
+// pojazd danego typu. Model traktuje wszystkie dystrybutory jak jedną pulę,
+// więc przy wydzielonych stanowiskach jest tylko przybliżeniem.
+func (s *Scenario) flowRate(vehicleType VehicleType) float64 {
+	var sum float64
+	var n int
+	for _, pump := range s.Pumps {
+		if slices.Contains(pump.Vehicles, vehicleType) {
+			sum += pump.FlowRate
+			n++
+		}
+	}
+	if n == 0 {
+		return defaultFlowRate
+	}
+	return sum / float64(n)
+}
+
+// Utilization zwraca obciążenie dystrybutorów ρ = λ / (c·μ).
+// Dla ρ ≥ 1 kolejka rośnie bez ograniczeń; bez obsługi (μ = 0)
+// obciążenie jest nieskończone, o ile ktoś przyjeżdża.
+func (m QueueModel) Utilization() float64 {
+	if m.Mu <= 0 || m.Servers == 0 {
+		if m.Lambda == 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return m.Lambda / (float64(m.Servers) * m.Mu)
+}
+
+// ServiceTime zwraca średni czas obsługi 1/μ (0 - model bez obsługi)
+func (m QueueModel) ServiceTime() time.Duration {
+	if m.Mu <= 0 {
+		return 0
+	}
+	return time.Duration(float64(time.Second) / m.Mu)
+}
+
+// Stable sprawdza, czy kolejka ma stan ustalony
+func (m QueueModel) Stable() bool {
+	return m.Mu > 0 && m.Utilization() < 1
+}
+
+// WaitProbability zwraca prawdopodobieństwo czekania ze wzoru Erlanga C
+func (m QueueModel) WaitProbability() float64 {
+	if !m.Stable() {
+		return 1
+	}
+	a := m.Lambda / m.Mu
+	rho := m.Utilization()
+
+	// Kolejne wyrazy a^k/k! liczone iteracyjnie, bez silni
+	term, sum := 1.0, 0.0
+	for k := 0; k < m.Servers; k++ {
+		sum += term
+		term *= a / float64(k+1)
+	}
+	tail := term / (1 - rho)
+	return tail / (sum + tail)
+}
+
+// QueueLength zwraca oczekiwaną długość kolejki Lq
+func (m QueueModel) QueueLength() float64 {
+	if !m.Stable() {
+		return math.Inf(1)
+	}
+	rho := m.Utilization()
+	return m.WaitProbability() * rho / (1 - rho)
+}
+
+// WaitTime zwraca oczekiwany czas czekania Wq = Lq / λ w sekundach
+func (m QueueModel) WaitTime() float64 {
+	if m.Lambda == 0 {
+		return 0
+	}
+	return m.QueueLength() / m.Lambda
+}
+
+// printQueueReport wypisuje przewidywania modelu M/M/c obok wyników
+// symulacji. Wywoływana po zatrzymaniu stacji, z zablokowanym mutexem
+// statystyk.
+func printQueueReport(gs *GasStation) {
+	m := NewQueueModel(gs.Scenario, gs.StartTime)
+	elapsed := gs.StopTime.Sub(gs.StartTime)
+	stats := gs.Stats
+
+	var utilization float64
+	for _, pump := range gs.Pumps {
+		utilization += pump.Utilization(elapsed) / float64(len(gs.Pumps))
+	}
+	var waitProbability float64
+	if stats.ServedVehicles > 0 {
+		waitProbability = float64(stats.WaitedVehicles) / float64(stats.ServedVehicles)
+	}
+
+	service := "-"
+	if m.Mu > 0 {
+		service = m.ServiceTime().Round(time.Millisecond).String()
+	}
+	fmt.Println("\nModel M/M/c:")
+	fmt.Printf("  λ = %.4f pojazdów/s, μ = %.4f pojazdów/s (średnia obsługa %s), c = %d\n",
+		m.Lambda, m.Mu, service, m.Servers)
+	fmt.Println("  Wielkość                   Teoria     Symulacja  Błąd względny")
+	printReportRow("Intensywność przyjazdów", m.Lambda, float64(stats.TotalVehicles)/elapsed.Seconds())
+	printReportRow("Obciążenie ρ", m.Utilization(), utilization)
+	printReportRow("P(czekanie)", m.WaitProbability(), waitProbability)
+	printReportRow("Długość kolejki Lq", m.QueueLength(), stats.AverageQueueLength())
+	printReportRow("Czas czekania Wq [s]", m.WaitTime(), stats.AverageWaitTime.Seconds())
+
+	if !m.Stable() {
+		fmt.Printf("  UWAGA: scenariusz niestabilny (ρ = %.2f ≥ 1) - kolejka rośnie bez ograniczeń,\n", m.Utilization())
+		fmt.Println("  a wyniki symulacji zależą od czasu jej trwania.")
+	}
+}
+
+// printReportRow wypisuje wiersz raportu z błędem względnym symulacji
+func printReportRow(name string, theory, simulated float64) {
+	relErr := "-"
+	if theory != 0 && !math.IsInf(theory, 0) {
+		relErr = fmt.Sprintf("%+.1f%%", 100*(simulated-theory)/theory)
+	}
+	fmt.Printf("  %-24s %9s  %12.4f  %13s\n", name, formatTheory(theory), simulated, relErr)
+}
+
+// formatTheory wypisuje wartość teoretyczną; nieskończoność oznacza brak
+// stanu ustalonego
+func formatTheory(v float64) string {
+	if math.IsInf(v, 0) {
+		return "∞"
+	}
+	return fmt.Sprintf("%.4f", v)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestQueueModel(t *testing.T) {
+	tests := []struct {
+		name        string
+		model       QueueModel
+		rho, pw, lq float64
+		wq          float64 // sekundy
+		stable      bool
+		serviceTime time.Duration
+	}{
+		// M/M/1: P(czekanie) = ρ, Lq = ρ²/(1-ρ)
+		{"M/M/1, ρ = 0,5", QueueModel{Lambda: 0.5, Mu: 1, Servers: 1}, 0.5, 0.5, 0.5, 1, true, time.Second},
+		// M/M/2 z a = λ/μ = 1: C(2, 1) = 1/3, Lq = C·ρ/(1-ρ) = 1/3
+		{"M/M/2, ρ = 0,5", QueueModel{Lambda: 1, Mu: 1, Servers: 2}, 0.5, 1.0 / 3, 1.0 / 3, 1.0 / 3, true, time.Second},
+		// Przy ρ ≥ 1 nie ma stanu ustalonego: każdy czeka, kolejka rośnie
+		{"ρ = 1", QueueModel{Lambda: 2, Mu: 1, Servers: 2}, 1, 1, math.Inf(1), math.Inf(1), false, time.Second},
+		{"ρ = 1,5", QueueModel{Lambda: 3, Mu: 1, Servers: 2}, 1.5, 1, math.Inf(1), math.Inf(1), false, time.Second},
+		{"bez obsługi", QueueModel{Lambda: 1, Mu: 0, Servers: 2}, math.Inf(1), 1, math.Inf(1), math.Inf(1), false, 0},
+	}
+	near := func(a, b float64) bool {
+		return a == b || math.Abs(a-b) < 1e-12
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.model
+			if got := m.Utilization(); !near(got, tt.rho) {
+				t.Errorf("ρ = %v, oczekiwano %v", got, tt.rho)
+			}
+			if got := m.Stable(); got != tt.stable {
+				t.Errorf("Stable() = %v, oczekiwano %v", got, tt.stable)
+			}
+			if got := m.WaitProbability(); !near(got, tt.pw) {
+				t.Errorf("P(czekanie) = %v, oczekiwano %v", got, tt.pw)
+			}
+			if got := m.QueueLength(); !near(got, tt.lq) {
+				t.Errorf("Lq = %v, oczekiwano %v", got, tt.lq)
+			}
+			if got := m.WaitTime(); !near(got, tt.wq) {
+				t.Errorf("Wq = %v, oczekiwano %v", got, tt.wq)
+			}
+			if got := m.ServiceTime(); got != tt.serviceTime {
+				t.Errorf("czas obsługi %v, oczekiwano %v", got, tt.serviceTime)
+			}
+		})
+	}
+}
@@ -0,0 +1,909 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Scenario opisuje konfigurację symulacji: dystrybutory, ceny, strumienie
+// przyjazdów pojazdów, czas trwania i ziarno losowania
+type Scenario struct {
+	Duration        time.Duration
+	Seed            int64
+	QueueCapacity   int
+	Cashiers        int
+	TankCapacity    float64
+	TankRefillLevel float64
+	Pumps           []PumpConfig
+	Prices          map[FuelType]float64
+	Vehicles        []VehicleProfile
+	SampleInterval  time.Duration // co ile zapisywać długość kolejki
+	Crew            int           // liczba serwisantów naprawiających dystrybutory
+	Maintenance     []MaintenanceWindow
+	PriorityAging   time.Duration // czas czekania podnoszący priorytet o poziom
+	Pricing         PricingPolicy
+	Shop            ShopConfig // sklep i myjnia odwiedzane po tankowaniu
+	Wash            WashConfig
+	Schedule        ScheduleConfig // godziny otwarcia i zmiany obsługi
+}
+
+// PumpConfig opisuje dystrybutor: sprzedawane paliwa, wydajność, typy
+// pojazdów, które się przy nim mieszczą, i awaryjność. MTBF to średni
+// czas między awariami, MTTR - średni czas naprawy; zerowy MTBF oznacza
+// dystrybutor, który się nie psuje.
+type PumpConfig struct {
+	FuelTypes []FuelType
+	FlowRate  float64 // litry na sekundę
+	Vehicles  []VehicleType
+	MTBF      time.Duration
+	MTTR      time.Duration
+}
+
+// Wydajność zwykłego dystrybutora (litry na sekundę), gdy scenariusz jej
+// nie podaje: 100 ms na litr
+const defaultFlowRate = 10.0
+
+// MaintenanceWindow to zaplanowany przegląd dystrybutora Pump (numer
+// od 1), zaczynający się Start po uruchomieniu stacji
+type MaintenanceWindow struct {
+	Pump     int
+	Start    time.Duration
+	Duration time.Duration
+}
+
+// VehicleProfile opisuje strumień przyjazdów pojazdów jednego typu
+type VehicleProfile struct {
+	Type          VehicleType
+	Arrival       Distribution
+	MinFuelAmount float64 // litry
+	MaxFuelAmount float64
+	FuelTypes     []FuelType
+	// QueueTolerance to najdłuższa kolejka, do której kierowca dołączy;
+	// Patience to średni czas, po którym zrezygnuje z czekania
+	QueueTolerance int
+	Patience       time.Duration
+	// Priority to klasa priorytetu pojazdów; przy FleetPriority kierowcy
+	// płacący kartą flotową dostają co najmniej klasę flotową
+	Priority      Priority
+	FleetPriority bool
+	// Wrażliwość kierowców na cenę (patrz Vehicle.PriceElasticity)
+	PriceElasticity float64
+	PriceLimit      float64
+	// Zamówienia: udziały trybów innych niż litry, kwoty zamówień za
+	// kwotę, pojemność baku i paliwo w baku przy przyjeździe (ułamek
+	// pojemności); zerowe MaxTank - bak nieznany
+	Orders       []orderShare
+	OrderAmounts []float64
+	MinTank      float64
+	MaxTank      float64
+	MinTankLevel float64
+	MaxTankLevel float64
+}
+
+// Domyślna tolerancja kolejki i cierpliwość kierowców według typu pojazdu.
+// Kierowcy ciężarówek nie mają wyboru stacji, więc czekają najdłużej;
+// pojazdy uprzywilejowane zawsze dołączają i nie rezygnują (cierpliwość 0).
+var (
+	defaultQueueTolerance = map[VehicleType]int{Car: 8, Truck: 15, Motorcycle: 5, Emergency: math.MaxInt}
+	defaultPatience       = map[VehicleType]time.Duration{
+		Car:        3 * time.Minute,
+		Truck:      10 * time.Minute,
+		Motorcycle: 2 * time.Minute,
+	}
+)
+
+// NewVehicle losuje pojazd według profilu; ID nadaje stacja
+func (p VehicleProfile) NewVehicle(rng *rand.Rand) *Vehicle {
+	fuelType := p.FuelTypes[rng.Intn(len(p.FuelTypes))]
+	fuelAmount := p.MinFuelAmount + rng.Float64()*(p.MaxFuelAmount-p.MinFuelAmount)
+
+	payment := Card
+	r := rng.Float64()
+	for _, ps := range paymentShares[p.Type] {
+		if r < ps.share {
+			payment = ps.method
+			break
+		}
+		r -= ps.share
+	}
+
+	// Cierpliwość konkretnego kierowcy: od połowy do półtorej średniej
+	patience := time.Duration((0.5 + rng.Float64()) * float64(p.Patience))
+
+	priority := p.Priority
+	if p.FleetPriority && payment == FleetCard {
+		priority = max(priority, PriorityFleet)
+	}
+
+	vehicle := &Vehicle{
+		Type:            p.Type,
+		FuelType:        fuelType,
+		FuelAmount:      fuelAmount,
+		WaitsForRefill:  rng.Intn(2) == 0,
+		Payment:         payment,
+		Priority:        priority,
+		QueueTolerance:  p.QueueTolerance,
+		Patience:        patience,
+		PriceElasticity: p.PriceElasticity,
+		PriceLimit:      p.PriceLimit,
+	}
+
+	// Bak i zamówienie losowane są tylko wtedy, gdy profil je opisuje,
+	// więc pozostałe scenariusze dają te same pojazdy co wcześniej
+	if p.MaxTank > 0 {
+		vehicle.TankCapacity = p.MinTank + rng.Float64()*(p.MaxTank-p.MinTank)
+		vehicle.TankLevel = vehicle.TankCapacity * (p.MinTankLevel + rng.Float64()*(p.MaxTankLevel-p.MinTankLevel))
+		vehicle.FuelAmount = min(vehicle.FuelAmount, vehicle.freeSpace())
+	}
+	if len(p.Orders) > 0 {
+		r := rng.Float64()
+		for _, order := range p.Orders {
+			if r < order.share {
+				vehicle.Order = order.mode
+				break
+			}
+			r -= order.share
+		}
+		if vehicle.Order == OrderAmount {
+			vehicle.MoneyLimit = p.OrderAmounts[rng.Intn(len(p.OrderAmounts))]
+		}
+	}
+	return vehicle
+}
+
+// Nazwy rodzajów paliwa i typów pojazdów używane w plikach scenariuszy
+var fuelTypeNames = map[string]FuelType{
+	"gasoline95": Gasoline95,
+	"gasoline98": Gasoline98,
+	"diesel":     Diesel,
+	"lpg":        LPG,
+}
+
+var vehicleTypeNames = map[string]VehicleType{
+	"car":        Car,
+	"truck":      Truck,
+	"motorcycle": Motorcycle,
+	"emergency":  Emergency,
+}
+
+var paymentMethodNames = map[string]PaymentMethod{
+	"card":       Card,
+	"cash":       Cash,
+	"fleet_card": FleetCard,
+}
+
+// nameOf zwraca nazwę wartości z mapy nazw, np. "diesel" dla Diesel
+func nameOf[T comparable](names map[string]T, value T) string {
+	for name, v := range names {
+		if v == value {
+			return name
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+// scenarioFile to postać scenariusza w pliku JSON
+type scenarioFile struct {
+	Duration       string             `json:"duration"`
+	Seed           int64              `json:"seed"`
+	QueueCapacity  int                `json:"queue_capacity"`
+	Cashiers       int                `json:"cashiers"`
+	Tanks          tankFile           `json:"tanks"`
+	Prices         map[string]float64 `json:"prices"`
+	Pumps          []pumpFile         `json:"pumps"`
+	Vehicles       []vehicleFile      `json:"vehicles"`
+	SampleInterval string             `json:"sample_interval"`
+	Maintenance    maintenanceFile    `json:"maintenance"`
+	PriorityAging  string             `json:"priority_aging"`
+	FleetPriority  bool               `json:"fleet_priority,omitempty"`
+	Pricing        pricingFile        `json:"pricing"`
+	Shop           shopFile           `json:"shop"`
+	Wash           washFile           `json:"wash"`
+	Schedule       scheduleFile       `json:"schedule"`
+}
+
+type shopFile struct {
+	Share        float64           `json:"share"`
+	Browse       durationRangeFile `json:"browse"`
+	Basket       rangeFile         `json:"basket"`
+	CheckoutTime string            `json:"checkout_time"`
+}
+
+type washFile struct {
+	Share          float64           `json:"share"`
+	Bays           int               `json:"bays"`
+	Vehicles       []string          `json:"vehicles"`
+	Duration       durationRangeFile `json:"duration"`
+	Price          float64           `json:"price"`
+	QueueTolerance int               `json:"queue_tolerance"`
+}
+
+type scheduleFile struct {
+	Open   string      `json:"open,omitempty"`
+	Close  string      `json:"close,omitempty"`
+	Shifts []shiftFile `json:"shifts,omitempty"`
+}
+
+type shiftFile struct {
+	Name     string `json:"name"`
+	Start    string `json:"start"`
+	Cashiers int    `json:"cashiers"`
+	Pumps    []int  `json:"pumps,omitempty"`
+}
+
+type pricingFile struct {
+	Strategy          string    `json:"strategy"`
+	Interval          string    `json:"interval"`
+	HourlyMultipliers []float64 `json:"hourly_multipliers"`
+	SurgePerVehicle   float64   `json:"surge_per_vehicle"`
+	MaxSurge          float64   `json:"max_surge"`
+	StockPremium      float64   `json:"stock_premium"`
+}
+
+type tankFile struct {
+	Capacity    float64 `json:"capacity"`
+	RefillLevel float64 `json:"refill_level"`
+}
+
+type pumpFile struct {
+	Fuels    []string `json:"fuels"`
+	FlowRate float64  `json:"flow_rate,omitempty"`
+	Vehicles []string `json:"vehicles,omitempty"`
+	MTBF     string   `json:"mtbf,omitempty"`
+	MTTR     string   `json:"mttr,omitempty"`
+}
+
+type maintenanceFile struct {
+	Crew    int          `json:"crew"`
+	Windows []windowFile `json:"windows,omitempty"`
+}
+
+type windowFile struct {
+	Pump     int    `json:"pump"`
+	Start    string `json:"start"`
+	Duration string `json:"duration"`
+}
+
+type vehicleFile struct {
+	Type           string      `json:"type"`
+	Arrival        arrivalFile `json:"arrival"`
+	FuelAmount     rangeFile   `json:"fuel_amount"`
+	Fuels          []string    `json:"fuels"`
+	QueueTolerance *int        `json:"queue_tolerance,omitempty"`
+	Patience       string      `json:"patience,omitempty"`
+	Priority       string      `json:"priority,omitempty"`
+	// Wskaźniki, bo zero jest poprawną wartością różną od domyślnej
+	PriceElasticity *float64 `json:"price_elasticity,omitempty"`
+	PriceLimit      *float64 `json:"price_limit,omitempty"`
+	// Zamówienia do pełna i za kwotę; pozostali kierowcy zamawiają litry
+	Orders       map[string]float64 `json:"orders,omitempty"`
+	OrderAmounts []float64          `json:"order_amounts,omitempty"`
+	Tank         rangeFile          `json:"tank,omitempty"`
+	TankLevel    rangeFile          `json:"tank_level,omitempty"`
+}
+
+type arrivalFile struct {
+	Distribution string    `json:"distribution"`
+	Min          string    `json:"min,omitempty"`
+	Max          string    `json:"max,omitempty"`
+	Mean         string    `json:"mean,omitempty"`
+	HourlyRates  []float64 `json:"hourly_rates,omitempty"`
+}
+
+type rangeFile struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+type durationRangeFile struct {
+	Min string `json:"min"`
+	Max string `json:"max"`
+}
+
+// defaultScenarioFile to wbudowana konfiguracja stacji. Pola pominięte
+// w pliku scenariusza zachowują te wartości.
+func defaultScenarioFile() scenarioFile {
+	allFuels := []string{"gasoline95", "gasoline98", "diesel", "lpg"}
+	every := arrivalFile{Distribution: "uniform", Min: "3s", Max: "9s"}
+
+	return scenarioFile{
+		Duration:       "60s",
+		SampleInterval: "1s",
+		PriorityAging:  "1m",
+		QueueCapacity:  50,
+		Cashiers:       2,
+		// Mały zbiornik, żeby braki pojawiały się w krótkiej symulacji
+		Tanks:       tankFile{Capacity: 500, RefillLevel: 150},
+		Maintenance: maintenanceFile{Crew: 1},
+		Pricing: pricingFile{
+			Strategy: "fixed",
+			Interval: "1m",
+			// Taniej w nocy, drożej w porannym i popołudniowym szczycie
+			HourlyMultipliers: []float64{
+				0.97, 0.97, 0.97, 0.97, 0.97, 0.98,
+				1.02, 1.05, 1.05, 1.02, 1.00, 1.00,
+				1.00, 1.00, 1.00, 1.02, 1.05, 1.05,
+				1.02, 1.00, 0.99, 0.98, 0.97, 0.97,
+			},
+			SurgePerVehicle: 0.02,
+			MaxSurge:        0.15,
+			StockPremium:    0.10,
+		},
+		// Sklep i myjnia są wyłączone (share 0), dopóki scenariusz ich nie włączy
+		Shop: shopFile{
+			Browse:       durationRangeFile{Min: "1m", Max: "4m"},
+			Basket:       rangeFile{Min: 5, Max: 60},
+			CheckoutTime: "15s",
+		},
+		Wash: washFile{
+			Bays:           1,
+			Vehicles:       []string{"car"},
+			Duration:       durationRangeFile{Min: "5m", Max: "8m"},
+			Price:          35,
+			QueueTolerance: 4,
+		},
+		Prices: map[string]float64{
+			"gasoline95": 6.50,
+			"gasoline98": 7.20,
+			"diesel":     6.80,
+			"lpg":        3.50,
+		},
+		Pumps: []pumpFile{
+			{Fuels: []string{"gasoline95", "gasoline98", "diesel"}},
+			{Fuels: []string{"gasoline95", "gasoline98", "diesel"}},
+			{Fuels: []string{"diesel"}}, // pas dla ciężarówek
+			{Fuels: []string{"lpg"}},    // wyspa LPG
+		},
+		Vehicles: []vehicleFile{
+			{Type: "car", Arrival: every, FuelAmount: rangeFile{20, 60}, Fuels: allFuels},
+			{Type: "truck", Arrival: every, FuelAmount: rangeFile{50, 200}, Fuels: allFuels},
+			{Type: "motorcycle", Arrival: every, FuelAmount: rangeFile{5, 20}, Fuels: allFuels},
+		},
+	}
+}
+
+// DefaultScenario zwraca wbudowany scenariusz
+func DefaultScenario() *Scenario {
+	s, err := defaultScenarioFile().build()
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// LoadScenario wczytuje scenariusz z pliku JSON i sprawdza jego poprawność.
+// Błędy wskazują pole, którego dotyczą, np. pumps[2].fuels[0].
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Listy z pliku zastępują domyślne w całości (dekoder JSON scaliłby
+	// elementy z domyślnymi), a ceny są uzupełniane pojedynczo
+	defaults := defaultScenarioFile()
+	file := defaults
+	file.Pumps, file.Vehicles = nil, nil
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, describeJSONError(data, err))
+	}
+	if file.Pumps == nil {
+		file.Pumps = defaults.Pumps
+	}
+	if file.Vehicles == nil {
+		file.Vehicles = defaults.Vehicles
+	}
+
+	s, err := file.build()
+	if err != nil {
+		return nil, fmt.Errorf("%s: błędny scenariusz:\n%w", path, err)
+	}
+	return s, nil
+}
+
+// describeJSONError dodaje do błędu dekodowania numer wiersza i kolumny
+func describeJSONError(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		line, col := position(data, syntaxErr.Offset)
+		return fmt.Errorf("wiersz %d, kolumna %d: %v", line, col, syntaxErr)
+	case errors.As(err, &typeErr):
+		line, col := position(data, typeErr.Offset)
+		return fmt.Errorf("wiersz %d, kolumna %d: %s: oczekiwano %s, jest %s",
+			line, col, typeErr.Field, typeErr.Type, typeErr.Value)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		return fmt.Errorf("nieznane pole %s", strings.TrimPrefix(err.Error(), "json: unknown field "))
+	default:
+		return err
+	}
+}
+
+// position zamienia przesunięcie w bajtach na wiersz i kolumnę
+func position(data []byte, offset int64) (line, col int) {
+	line, col = 1, 1
+	for _, b := range data[:min(int(offset), len(data))] {
+		if b == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return line, col
+}
+
+// scenarioErrors zbiera błędy walidacji razem ze ścieżką pola
+type scenarioErrors []error
+
+func (e *scenarioErrors) add(field, format string, args ...any) {
+	*e = append(*e, fmt.Errorf("  %s: %s", field, fmt.Sprintf(format, args...)))
+}
+
+// build sprawdza scenariusz i zamienia go na postać używaną przez stację
+func (f scenarioFile) build() (*Scenario, error) {
+	var errs scenarioErrors
+	s := &Scenario{
+		Seed:            f.Seed,
+		QueueCapacity:   f.QueueCapacity,
+		Cashiers:        f.Cashiers,
+		TankCapacity:    f.Tanks.Capacity,
+		TankRefillLevel: f.Tanks.RefillLevel,
+		Prices:          make(map[FuelType]float64),
+	}
+
+	s.Duration = parseDuration(&errs, "duration", f.Duration)
+	s.SampleInterval = parseDuration(&errs, "sample_interval", f.SampleInterval)
+	s.PriorityAging = parseDuration(&errs, "priority_aging", f.PriorityAging)
+	s.Pricing = parsePricing(&errs, "pricing", f.Pricing)
+	s.Shop = parseShop(&errs, "shop", f.Shop)
+	s.Wash = parseWash(&errs, "wash", f.Wash)
+	if s.QueueCapacity < 1 {
+		errs.add("queue_capacity", "musi być dodatnie, jest %d", f.QueueCapacity)
+	}
+	if s.Cashiers < 1 {
+		errs.add("cashiers", "potrzebna jest co najmniej jedna kasa, jest %d", f.Cashiers)
+	}
+	if s.TankCapacity <= 0 {
+		errs.add("tanks.capacity", "musi być dodatnia, jest %g", f.Tanks.Capacity)
+	}
+	if s.TankRefillLevel < 0 || s.TankRefillLevel >= s.TankCapacity {
+		errs.add("tanks.refill_level", "musi być w przedziale [0, %g), jest %g", f.Tanks.Capacity, f.Tanks.RefillLevel)
+	}
+
+	for _, name := range sortedKeys(f.Prices) {
+		field := fmt.Sprintf("prices.%s", name)
+		ft, ok := fuelTypeNames[name]
+		if !ok {
+			errs.add(field, "nieznany rodzaj paliwa (dozwolone: %v)", sortedKeys(fuelTypeNames))
+			continue
+		}
+		if f.Prices[name] <= 0 {
+			errs.add(field, "cena musi być dodatnia, jest %g", f.Prices[name])
+		}
+		s.Prices[ft] = f.Prices[name]
+	}
+
+	if len(f.Pumps) == 0 {
+		errs.add("pumps", "potrzebny jest co najmniej jeden dystrybutor")
+	}
+	for i, pump := range f.Pumps {
+		field := fmt.Sprintf("pumps[%d]", i)
+		fuels := parseFuels(&errs, field+".fuels", pump.Fuels)
+		for j, ft := range fuels {
+			if _, ok := s.Prices[ft]; !ok {
+				errs.add(fmt.Sprintf("%s.fuels[%d]", field, j), "brak ceny w prices dla %q", pump.Fuels[j])
+			}
+		}
+		config := PumpConfig{
+			FuelTypes: fuels,
+			FlowRate:  defaultFlowRate,
+			Vehicles:  parseVehicleTypes(&errs, field+".vehicles", pump.Vehicles),
+		}
+		if pump.FlowRate < 0 {
+			errs.add(field+".flow_rate", "musi być dodatnia, jest %g", pump.FlowRate)
+		} else if pump.FlowRate > 0 {
+			config.FlowRate = pump.FlowRate
+		}
+		if (pump.MTBF == "") != (pump.MTTR == "") {
+			errs.add(field, "mtbf i mttr podaje się razem")
+		} else if pump.MTBF != "" {
+			config.MTBF = parseDuration(&errs, field+".mtbf", pump.MTBF)
+			config.MTTR = parseDuration(&errs, field+".mttr", pump.MTTR)
+		}
+		s.Pumps = append(s.Pumps, config)
+	}
+
+	s.Crew = f.Maintenance.Crew
+	if s.Crew < 1 {
+		errs.add("maintenance.crew", "potrzebny jest co najmniej jeden serwisant, jest %d", f.Maintenance.Crew)
+	}
+	for i, w := range f.Maintenance.Windows {
+		field := fmt.Sprintf("maintenance.windows[%d]", i)
+		if w.Pump < 1 || w.Pump > len(f.Pumps) {
+			errs.add(field+".pump", "nie ma dystrybutora %d (dozwolone: 1-%d)", w.Pump, len(f.Pumps))
+		}
+		s.Maintenance = append(s.Maintenance, MaintenanceWindow{
+			Pump:     w.Pump,
+			Start:    parseDuration(&errs, field+".start", w.Start),
+			Duration: parseDuration(&errs, field+".duration", w.Duration),
+		})
+	}
+
+	s.Schedule = parseSchedule(&errs, "schedule", f.Schedule, len(f.Pumps), s.Cashiers)
+
+	if len(f.Vehicles) == 0 {
+		errs.add("vehicles", "potrzebny jest co najmniej jeden strumień pojazdów")
+	}
+	for i, v := range f.Vehicles {
+		field := fmt.Sprintf("vehicles[%d]", i)
+		vt, ok := vehicleTypeNames[v.Type]
+		if !ok {
+			errs.add(field+".type", "nieznany typ pojazdu %q (dozwolone: %v)", v.Type, sortedKeys(vehicleTypeNames))
+		}
+		if v.FuelAmount.Min <= 0 || v.FuelAmount.Max < v.FuelAmount.Min {
+			errs.add(field+".fuel_amount", "wymagane 0 < min <= max, jest min=%g, max=%g", v.FuelAmount.Min, v.FuelAmount.Max)
+		} else if s.TankCapacity > 0 && v.FuelAmount.Max > s.TankCapacity {
+			errs.add(field+".fuel_amount.max", "przekracza pojemność zbiornika (tanks.capacity = %g), jest %g", s.TankCapacity, v.FuelAmount.Max)
+		}
+
+		// Pominięte pola biorą wartości domyślne dla typu pojazdu
+		tolerance := defaultQueueTolerance[vt]
+		if v.QueueTolerance != nil {
+			tolerance = *v.QueueTolerance
+			if tolerance < 0 {
+				errs.add(field+".queue_tolerance", "nie może być ujemna, jest %d", tolerance)
+			}
+		}
+		patience := defaultPatience[vt]
+		if v.Patience != "" {
+			patience = parseDuration(&errs, field+".patience", v.Patience)
+		}
+		priority := defaultPriority[vt]
+		if v.Priority != "" {
+			var ok bool
+			if priority, ok = priorityNames[v.Priority]; !ok {
+				errs.add(field+".priority", "nieznana klasa priorytetu %q (dozwolone: %v)", v.Priority, sortedKeys(priorityNames))
+			}
+		}
+
+		profile := VehicleProfile{
+			Type:           vt,
+			Arrival:        parseArrival(&errs, field+".arrival", v.Arrival),
+			MinFuelAmount:  v.FuelAmount.Min,
+			MaxFuelAmount:  v.FuelAmount.Max,
+			FuelTypes:      parseFuels(&errs, field+".fuels", v.Fuels),
+			QueueTolerance: tolerance,
+			Patience:       patience,
+			Priority:       priority,
+			FleetPriority:  f.FleetPriority,
+		}
+		if v.PriceElasticity != nil {
+			profile.PriceElasticity = *v.PriceElasticity
+			if profile.PriceElasticity < 0 {
+				errs.add(field+".price_elasticity", "nie może być ujemna, jest %g", profile.PriceElasticity)
+			}
+		}
+		if v.PriceLimit != nil {
+			profile.PriceLimit = *v.PriceLimit
+			if profile.PriceLimit < 0 {
+				errs.add(field+".price_limit", "nie może być ujemny, jest %g", profile.PriceLimit)
+			}
+		}
+		parseOrders(&errs, field, v, s.TankCapacity, &profile)
+		s.Vehicles = append(s.Vehicles, profile)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return s, nil
+}
+
+// parseOrders sprawdza bak i zamówienia strumienia pojazdów field
+// i zapisuje je w profilu. Bak nie może być większy od zbiornika stacji
+// o pojemności tankCapacity - tankowanie do pełna by się nie zmieściło.
+func parseOrders(errs *scenarioErrors, field string, v vehicleFile, tankCapacity float64, profile *VehicleProfile) {
+	if v.Tank != (rangeFile{}) || v.TankLevel != (rangeFile{}) {
+		if v.Tank.Min <= 0 || v.Tank.Max < v.Tank.Min {
+			errs.add(field+".tank", "wymagane 0 < min <= max, jest min=%g, max=%g", v.Tank.Min, v.Tank.Max)
+		} else if tankCapacity > 0 && v.Tank.Max > tankCapacity {
+			errs.add(field+".tank.max", "przekracza pojemność zbiornika (tanks.capacity = %g), jest %g", tankCapacity, v.Tank.Max)
+		}
+		if v.TankLevel.Min < 0 || v.TankLevel.Max < v.TankLevel.Min || v.TankLevel.Max > 1 {
+			errs.add(field+".tank_level", "wymagane 0 <= min <= max <= 1, jest min=%g, max=%g", v.TankLevel.Min, v.TankLevel.Max)
+		}
+		profile.MinTank, profile.MaxTank = v.Tank.Min, v.Tank.Max
+		profile.MinTankLevel, profile.MaxTankLevel = v.TankLevel.Min, v.TankLevel.Max
+	}
+
+	// Udziały w kolejności allOrderModes, żeby losowanie nie zależało od
+	// kolejności kluczy w pliku
+	total := 0.0
+	for _, mode := range allOrderModes {
+		share := v.Orders[nameOf(orderModeNames, mode)]
+		if share <= 0 {
+			continue
+		}
+		total += share
+		profile.Orders = append(profile.Orders, orderShare{mode, share})
+	}
+	for _, name := range sortedKeys(v.Orders) {
+		share := v.Orders[name]
+		if _, ok := orderModeNames[name]; !ok {
+			errs.add(field+".orders", "nieznany tryb zamówienia %q (dozwolone: %v)", name, sortedKeys(orderModeNames))
+		} else if share < 0 || share > 1 {
+			errs.add(field+".orders."+name, "musi być w przedziale [0, 1], jest %g", share)
+		}
+	}
+	if total > 1+1e-9 {
+		errs.add(field+".orders", "udziały sumują się do %g, więcej niż 1", total)
+	}
+	if v.Orders["full"] > 0 && profile.MaxTank == 0 {
+		errs.add(field+".tank", "zamówienia do pełna wymagają pojemności baku")
+	}
+	if v.Orders["amount"] > 0 && len(v.OrderAmounts) == 0 {
+		errs.add(field+".order_amounts", "zamówienia za kwotę wymagają co najmniej jednej kwoty")
+	}
+	for i, amount := range v.OrderAmounts {
+		if amount <= 0 {
+			errs.add(fmt.Sprintf("%s.order_amounts[%d]", field, i), "kwota musi być dodatnia, jest %g", amount)
+		}
+	}
+	profile.OrderAmounts = v.OrderAmounts
+}
+
+// parseDuration zamienia np. "90s" na czas trwania większy od zera
+func parseDuration(errs *scenarioErrors, field, value string) time.Duration {
+	d, err := time.ParseDuration(value)
+	switch {
+	case err != nil:
+		errs.add(field, "niepoprawny czas %q (przykład: \"90s\", \"24h\")", value)
+	case d <= 0:
+		errs.add(field, "musi być dodatni, jest %q", value)
+	}
+	return d
+}
+
+// parseFuels zamienia niepustą listę nazw paliw na rodzaje paliwa
+func parseFuels(errs *scenarioErrors, field string, names []string) []FuelType {
+	if len(names) == 0 {
+		errs.add(field, "lista paliw nie może być pusta")
+	}
+	fuels := make([]FuelType, 0, len(names))
+	for i, name := range names {
+		ft, ok := fuelTypeNames[name]
+		if !ok {
+			errs.add(fmt.Sprintf("%s[%d]", field, i), "nieznany rodzaj paliwa %q (dozwolone: %v)", name, sortedKeys(fuelTypeNames))
+			continue
+		}
+		fuels = append(fuels, ft)
+	}
+	return fuels
+}
+
+// parseVehicleTypes zamienia listę nazw typów pojazdów na typy pojazdów;
+// pusta lista oznacza wszystkie typy
+func parseVehicleTypes(errs *scenarioErrors, field string, names []string) []VehicleType {
+	if len(names) == 0 {
+		return slices.Clone(allVehicleTypes)
+	}
+	types := make([]VehicleType, 0, len(names))
+	for i, name := range names {
+		vt, ok := vehicleTypeNames[name]
+		if !ok {
+			errs.add(fmt.Sprintf("%s[%d]", field, i), "nieznany typ pojazdu %q (dozwolone: %v)", name, sortedKeys(vehicleTypeNames))
+			continue
+		}
+		types = append(types, vt)
+	}
+	return types
+}
+
+// parsePricing sprawdza strategię cenową i jej parametry
+func parsePricing(errs *scenarioErrors, field string, p pricingFile) PricingPolicy {
+	policy := PricingPolicy{
+		Strategy:        p.Strategy,
+		Interval:        parseDuration(errs, field+".interval", p.Interval),
+		SurgePerVehicle: p.SurgePerVehicle,
+		MaxSurge:        p.MaxSurge,
+		StockPremium:    p.StockPremium,
+	}
+	if !slices.Contains(pricingStrategies, p.Strategy) {
+		errs.add(field+".strategy", "nieznana strategia %q (dozwolone: %v)", p.Strategy, pricingStrategies)
+	}
+	if len(p.HourlyMultipliers) != 24 {
+		errs.add(field+".hourly_multipliers", "potrzebne są 24 wartości, jest %d", len(p.HourlyMultipliers))
+	} else {
+		for h, m := range p.HourlyMultipliers {
+			if m <= 0 {
+				errs.add(fmt.Sprintf("%s.hourly_multipliers[%d]", field, h), "musi być dodatni, jest %g", m)
+			}
+			policy.HourlyMultipliers[h] = m
+		}
+	}
+	for _, f := range []struct {
+		name  string
+		value float64
+	}{
+		{"surge_per_vehicle", p.SurgePerVehicle},
+		{"max_surge", p.MaxSurge},
+		{"stock_premium", p.StockPremium},
+	} {
+		if f.value < 0 {
+			errs.add(field+"."+f.name, "nie może być ujemne, jest %g", f.value)
+		}
+	}
+	return policy
+}
+
+// parseShare sprawdza udział kierowców odwiedzających sklep albo myjnię
+func parseShare(errs *scenarioErrors, field string, share float64) float64 {
+	if share < 0 || share > 1 {
+		errs.add(field, "musi być w przedziale [0, 1], jest %g", share)
+	}
+	return share
+}
+
+// parseDurationRange sprawdza zakres czasu {"min": ..., "max": ...}
+func parseDurationRange(errs *scenarioErrors, field string, r durationRangeFile) (minimum, maximum time.Duration) {
+	minimum = parseDuration(errs, field+".min", r.Min)
+	maximum = parseDuration(errs, field+".max", r.Max)
+	if maximum < minimum {
+		errs.add(field+".max", "musi być nie mniejsze niż min (%s)", r.Min)
+	}
+	return minimum, maximum
+}
+
+// parseTimeOfDay zamienia porę doby, np. "06:30", na czas od północy
+func parseTimeOfDay(errs *scenarioErrors, field, value string) time.Duration {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		errs.add(field, "niepoprawna pora doby %q (oczekiwano np. \"06:30\")", value)
+		return 0
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+}
+
+// parseSchedule sprawdza godziny otwarcia i zmiany obsługi stacji
+// z pumps dystrybutorami i cashiers kasami
+func parseSchedule(errs *scenarioErrors, field string, f scheduleFile, pumps, cashiers int) ScheduleConfig {
+	var schedule ScheduleConfig
+	if (f.Open == "") != (f.Close == "") {
+		errs.add(field, "godziny otwarcia wymagają pól open i close")
+	} else if f.Open != "" {
+		schedule.Open = parseTimeOfDay(errs, field+".open", f.Open)
+		schedule.Close = parseTimeOfDay(errs, field+".close", f.Close)
+	}
+
+	names := make(map[string]bool)
+	for i, sf := range f.Shifts {
+		sfield := fmt.Sprintf("%s.shifts[%d]", field, i)
+		shift := Shift{
+			Name:     sf.Name,
+			Start:    parseTimeOfDay(errs, sfield+".start", sf.Start),
+			Cashiers: sf.Cashiers,
+		}
+		if shift.Name == "" {
+			errs.add(sfield+".name", "zmiana potrzebuje nazwy")
+		} else if names[shift.Name] {
+			errs.add(sfield+".name", "powtórzona nazwa zmiany %q", shift.Name)
+		}
+		names[shift.Name] = true
+		if sf.Cashiers < 1 || sf.Cashiers > cashiers {
+			errs.add(sfield+".cashiers", "musi być w przedziale [1, %d] (liczba kas), jest %d", cashiers, sf.Cashiers)
+		}
+		if sf.Pumps != nil && len(sf.Pumps) == 0 {
+			errs.add(sfield+".pumps", "zmiana potrzebuje co najmniej jednego dystrybutora")
+		}
+		for j, id := range sf.Pumps {
+			if id < 1 || id > pumps {
+				errs.add(fmt.Sprintf("%s.pumps[%d]", sfield, j), "nie ma dystrybutora %d (dozwolone: 1-%d)", id, pumps)
+			} else if !slices.Contains(shift.Pumps, id) {
+				shift.Pumps = append(shift.Pumps, id)
+			}
+		}
+		schedule.Shifts = append(schedule.Shifts, shift)
+	}
+	slices.SortFunc(schedule.Shifts, func(a, b Shift) int { return int(a.Start - b.Start) })
+	for i := 1; i < len(schedule.Shifts); i++ {
+		if schedule.Shifts[i].Start == schedule.Shifts[i-1].Start {
+			errs.add(field+".shifts", "zmiany %q i %q zaczynają się o tej samej porze", schedule.Shifts[i-1].Name, schedule.Shifts[i].Name)
+		}
+	}
+	return schedule
+}
+
+// parseShop sprawdza konfigurację sklepu
+func parseShop(errs *scenarioErrors, field string, f shopFile) ShopConfig {
+	shop := ShopConfig{
+		Share:     parseShare(errs, field+".share", f.Share),
+		MinBasket: f.Basket.Min,
+		MaxBasket: f.Basket.Max,
+		Checkout:  parseDuration(errs, field+".checkout_time", f.CheckoutTime),
+	}
+	shop.MinBrowse, shop.MaxBrowse = parseDurationRange(errs, field+".browse", f.Browse)
+	if f.Basket.Min < 0 || f.Basket.Max < f.Basket.Min {
+		errs.add(field+".basket", "wymagane 0 <= min <= max, jest min=%g, max=%g", f.Basket.Min, f.Basket.Max)
+	}
+	return shop
+}
+
+// parseWash sprawdza konfigurację myjni
+func parseWash(errs *scenarioErrors, field string, f washFile) WashConfig {
+	wash := WashConfig{
+		Share:          parseShare(errs, field+".share", f.Share),
+		Bays:           f.Bays,
+		Vehicles:       parseVehicleTypes(errs, field+".vehicles", f.Vehicles),
+		Price:          f.Price,
+		QueueTolerance: f.QueueTolerance,
+	}
+	wash.MinDuration, wash.MaxDuration = parseDurationRange(errs, field+".duration", f.Duration)
+	if f.Bays < 1 {
+		errs.add(field+".bays", "potrzebne jest co najmniej jedno stanowisko, jest %d", f.Bays)
+	}
+	if f.Price < 0 {
+		errs.add(field+".price", "nie może być ujemna, jest %g", f.Price)
+	}
+	if f.QueueTolerance < 0 {
+		errs.add(field+".queue_tolerance", "nie może być ujemna, jest %d", f.QueueTolerance)
+	}
+	return wash
+}
+
+// parseArrival sprawdza rozkład odstępów między przyjazdami
+func parseArrival(errs *scenarioErrors, field string, a arrivalFile) Distribution {
+	d := Distribution{Kind: a.Distribution}
+	switch a.Distribution {
+	case "uniform":
+		d.Min = parseDuration(errs, field+".min", a.Min)
+		d.Max = parseDuration(errs, field+".max", a.Max)
+		if d.Max < d.Min {
+			errs.add(field+".max", "musi być nie mniejsze niż min (%s)", a.Min)
+		}
+	case "exponential":
+		d.Mean = parseDuration(errs, field+".mean", a.Mean)
+	case "hourly":
+		field += ".hourly_rates"
+		if len(a.HourlyRates) != 24 {
+			errs.add(field, "wymagane 24 wartości (godziny 0-23), jest %d", len(a.HourlyRates))
+			break
+		}
+		for h, rate := range a.HourlyRates {
+			if rate < 0 {
+				errs.add(fmt.Sprintf("%s[%d]", field, h), "liczba pojazdów na godzinę nie może być ujemna, jest %g", rate)
+			}
+			d.HourlyRates[h] = rate
+			d.MaxRate = max(d.MaxRate, rate)
+		}
+		if d.MaxRate == 0 {
+			errs.add(field, "co najmniej jedna godzina musi mieć dodatnią intensywność")
+		}
+	default:
+		errs.add(field+".distribution", "nieznany rozkład %q (dozwolone: uniform, exponential, hourly)", a.Distribution)
+	}
+	return d
+}
+
+// sortedKeys zwraca klucze mapy w porządku alfabetycznym
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildRejectsAmountsAboveTankCapacity(t *testing.T) {
+	f := defaultScenarioFile()
+	f.Tanks = tankFile{Capacity: 100, RefillLevel: 20}
+
+	_, err := f.build()
+	if err == nil {
+		t.Fatal("scenariusz z tankowaniem większym od zbiornika przeszedł walidację")
+	}
+	if !strings.Contains(err.Error(), "vehicles[1].fuel_amount.max: przekracza pojemność zbiornika") {
+		t.Errorf("brak błędu pola vehicles[1].fuel_amount.max w:\n%v", err)
+	}
+	if strings.Contains(err.Error(), "vehicles[0]") {
+		t.Errorf("błąd dla strumienia mieszczącego się w zbiorniku:\n%v", err)
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ScheduleConfig opisuje godziny otwarcia stacji i zmiany obsługi. Open
+// i Close to pory doby; równe oznaczają stację całodobową. Każda zmiana
+// trwa do początku następnej, a ostatnia - do początku pierwszej
+// następnego dnia. Bez zmian wszystkie kasy i dystrybutory pracują
+// cały czas.
+type ScheduleConfig struct {
+	Open, Close time.Duration
+	Shifts      []Shift // według pory rozpoczęcia
+}
+
+// Shift to zmiana obsługi: od pory doby Start na stacji pracuje Cashiers
+// kasjerów, a otwarte są dystrybutory Pumps (numery od 1; puste -
+// wszystkie). Zmiana z częścią dystrybutorów to np. tryb nocny.
+type Shift struct {
+	Name     string
+	Start    time.Duration
+	Cashiers int
+	Pumps    []int
+}
+
+// ShiftStatistics przechowuje jakość obsługi pojazdów przybyłych w czasie
+// jednej zmiany
+type ShiftStatistics struct {
+	Arrivals     int
+	Served       int
+	Lost         int // zrezygnowali z kolejki albo przez cenę
+	Closed       int // przyjechali do zamkniętej stacji albo odesłani przy zamknięciu
+	Wait         Histogram
+	CheckoutWait time.Duration // łączne czekanie na wolną kasę
+	Revenue      float64
+}
+
+// Zdarzenia harmonogramu: Event.Shift to nazwa zmiany
+const (
+	EventShiftStarted  = "shift_started"  // nowa zmiana obsługi
+	EventStationOpened = "station_opened" // początek godzin otwarcia
+	EventStationClosed = "station_closed" // koniec godzin otwarcia
+)
+
+// timeOfDay zwraca porę doby chwili t
+func timeOfDay(t time.Time) time.Duration {
+	h, m, s := t.Clock()
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute +
+		time.Duration(s)*time.Second + time.Duration(t.Nanosecond())
+}
+
+// formatTimeOfDay zapisuje porę doby jako "15:04"
+func formatTimeOfDay(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// enabled sprawdza, czy harmonogram cokolwiek zmienia w ciągu doby
+func (c ScheduleConfig) enabled() bool {
+	return c.Open != c.Close || len(c.Shifts) > 0
+}
+
+// isOpen sprawdza, czy o porze doby tod stacja jest otwarta
+func (c ScheduleConfig) isOpen(tod time.Duration) bool {
+	switch {
+	case c.Open == c.Close:
+		return true
+	case c.Open < c.Close:
+		return tod >= c.Open && tod < c.Close
+	default: // otwarta przez północ, np. 05:00-01:00
+		return tod >= c.Open || tod < c.Close
+	}
+}
+
+// shiftAt zwraca numer zmiany trwającej o porze doby tod (-1 - bez zmian)
+func (c ScheduleConfig) shiftAt(tod time.Duration) int {
+	if len(c.Shifts) == 0 {
+		return -1
+	}
+	current := len(c.Shifts) - 1 // przed pierwszą zmianą trwa jeszcze ostatnia z poprzedniego dnia
+	for i, shift := range c.Shifts {
+		if shift.Start <= tod {
+			current = i
+		}
+	}
+	return current
+}
+
+// next zwraca czas od pory doby tod do najbliższej zmiany harmonogramu:
+// początku zmiany, otwarcia albo zamknięcia stacji (stacja całodobowa
+// nie ma otwarcia ani zamknięcia)
+func (c ScheduleConfig) next(tod time.Duration) time.Duration {
+	var boundaries []time.Duration
+	if c.Open != c.Close {
+		boundaries = append(boundaries, c.Open, c.Close)
+	}
+	for _, shift := range c.Shifts {
+		boundaries = append(boundaries, shift.Start)
+	}
+	wait := 24 * time.Hour
+	for _, b := range boundaries {
+		d := b - tod
+		if d <= 0 {
+			d += 24 * time.Hour
+		}
+		wait = min(wait, d)
+	}
+	return wait
+}
+
+// end zwraca porę doby, o której kończy się zmiana i
+func (c ScheduleConfig) end(i int) time.Duration {
+	return c.Shifts[(i+1)%len(c.Shifts)].Start
+}
+
+// hasPump sprawdza, czy dystrybutor id jest otwarty na zmianie
+func (s Shift) hasPump(id int) bool {
+	return len(s.Pumps) == 0 || slices.Contains(s.Pumps, id)
+}
+
+// startSchedule ustawia kasy i dystrybutory według pory startu
+// i uruchamia goroutine harmonogramu, która zmienia je na początku
+// każdej zmiany oraz przy otwarciu i zamknięciu stacji
+func (gs *GasStation) startSchedule() {
+	schedule := gs.Scenario.Schedule
+	if !schedule.enabled() {
+		return
+	}
+	gs.applySchedule(true)
+	gs.goTracked(func() {
+		for gs.pause(schedule.next(timeOfDay(gs.Clock.Now()))) {
+			gs.applySchedule(false)
+		}
+	})
+}
+
+// applySchedule ustawia kasjerów, dystrybutory i otwarcie stacji według
+// bieżącej pory doby. Dystrybutor spoza zmiany kończy obsługę bieżącego
+// pojazdu i nie przyjmuje kolejnych. Przy zamknięciu stacji kierowcy
+// czekający w kolejce odjeżdżają.
+func (gs *GasStation) applySchedule(initial bool) {
+	schedule := gs.Scenario.Schedule
+	tod := timeOfDay(gs.Clock.Now())
+	open := schedule.isOpen(tod)
+	shiftIndex := schedule.shiftAt(tod)
+	var shift Shift
+	if shiftIndex >= 0 {
+		shift = schedule.Shifts[shiftIndex]
+		gs.Checkout.SetOnDuty(shift.Cashiers)
+	}
+
+	for _, pump := range gs.Pumps {
+		pump.mutex.Lock()
+		pump.OffShift = !open || !shift.hasPump(pump.ID)
+		gs.Queue.SetPumpOnline(pump, pump.inService())
+		pump.mutex.Unlock()
+	}
+
+	gs.mutex.Lock()
+	wasOpen, previous := gs.open, gs.shift
+	gs.open, gs.shift = open, shiftIndex
+	gs.mutex.Unlock()
+
+	if shiftIndex >= 0 && (initial || shiftIndex != previous) {
+		gs.Events.Publish(Event{Kind: EventShiftStarted, Shift: shift.Name})
+	}
+	switch {
+	case open && (initial || !wasOpen):
+		gs.Events.Publish(Event{Kind: EventStationOpened})
+	case !open && (initial || wasOpen):
+		gs.Events.Publish(Event{Kind: EventStationClosed})
+		gs.closeQueue()
+	}
+}
+
+// closeQueue odsyła kierowców czekających w kolejce przy zamknięciu stacji
+func (gs *GasStation) closeQueue() {
+	closed := gs.Queue.Clear()
+	for _, vehicle := range closed {
+		if vehicle.patienceTimer != nil {
+			vehicle.patienceTimer.Stop()
+		}
+		gs.publish(EventLeft, vehicle, Event{Reason: LeftClosed})
+	}
+
+	gs.Stats.mutex.Lock()
+	gs.Stats.ClosedVehicles += len(closed)
+	for _, vehicle := range closed {
+		if s := gs.Stats.shiftOf(vehicle); s != nil {
+			s.Closed++
+		}
+	}
+	gs.Stats.mutex.Unlock()
+}
+
+// isOpen sprawdza, czy stacja jest w godzinach otwarcia
+func (gs *GasStation) isOpen() bool {
+	gs.mutex.RLock()
+	defer gs.mutex.RUnlock()
+	return gs.open
+}
+
+// currentShift zwraca numer bieżącej zmiany (-1 - bez zmian)
+func (gs *GasStation) currentShift() int {
+	gs.mutex.RLock()
+	defer gs.mutex.RUnlock()
+	return gs.shift
+}
+
+// shiftOf zwraca statystyki zmiany, w której przyjechał pojazd (nil -
+// bez zmian). Wywoływana z zablokowanym mutexem statystyk.
+func (s *Statistics) shiftOf(vehicle *Vehicle) *ShiftStatistics {
+	if vehicle.shift < 0 || vehicle.shift >= len(s.Shifts) {
+		return nil
+	}
+	return s.Shifts[vehicle.shift]
+}
+
+// pumpList zwraca numery dystrybutorów zmiany, np. "1, 4" albo "wszystkie"
+func (s Shift) pumpList() string {
+	if len(s.Pumps) == 0 {
+		return "wszystkie"
+	}
+	ids := make([]string, len(s.Pumps))
+	for i, id := range s.Pumps {
+		ids[i] = fmt.Sprint(id)
+	}
+	return strings.Join(ids, ", ")
+}
+
+// printSchedule wypisuje w podsumowaniu godziny otwarcia i jakość obsługi
+// na każdej zmianie. Wywoływana z zablokowanym mutexem statystyk.
+func printSchedule(gs *GasStation) {
+	schedule := gs.Scenario.Schedule
+	hours := "całodobowo"
+	if schedule.Open != schedule.Close {
+		hours = formatTimeOfDay(schedule.Open) + "-" + formatTimeOfDay(schedule.Close)
+	}
+	fmt.Printf("\nHarmonogram (otwarte: %s):\n", hours)
+	fmt.Printf("  Odjechali poza godzinami otwarcia: %d\n", gs.Stats.ClosedVehicles)
+	if len(schedule.Shifts) == 0 {
+		return
+	}
+	fmt.Println("  Zmiana          Godziny      Kasjerzy  Dystrybutory  Przyjazdy  Obsłużeni  Utraceni  Zamknięte  Śr. czekanie  p90 czekania  Śr. czekanie na kasę  Przychód [PLN]")
+	for i, shift := range schedule.Shifts {
+		s := gs.Stats.Shifts[i]
+		avgWait, avgCheckout := time.Duration(0), time.Duration(0)
+		if s.Served > 0 {
+			avgWait = s.Wait.Sum() / time.Duration(s.Served)
+			avgCheckout = s.CheckoutWait / time.Duration(s.Served)
+		}
+		fmt.Printf("  %-14s  %s-%s  %8d  %-12s  %9d  %9d  %8d  %9d  %12v  %12v  %20v  %14.2f\n",
+			shift.Name, formatTimeOfDay(shift.Start), formatTimeOfDay(schedule.end(i)), shift.Cashiers,
+			shift.pumpList(), s.Arrivals, s.Served, s.Lost, s.Closed,
+			avgWait.Round(10*time.Millisecond), s.Wait.Quantile(0.9).Round(10*time.Millisecond),
+			avgCheckout.Round(10*time.Millisecond), s.Revenue)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// at zwraca porę doby hh:mm
+func at(h, m int) time.Duration {
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute
+}
+
+// Harmonogram dzienny jak w scenarios/shifts.json i stacja otwarta przez
+// północ z nocną zmianą trwającą do rana
+var (
+	daySchedule = ScheduleConfig{
+		Open:  at(5, 0),
+		Close: at(23, 0),
+		Shifts: []Shift{
+			{Name: "ranna", Start: at(5, 0)},
+			{Name: "popołudniowa", Start: at(14, 0)},
+			{Name: "wieczorna", Start: at(20, 0)},
+		},
+	}
+	overnightSchedule = ScheduleConfig{
+		Open:  at(5, 0),
+		Close: at(1, 0),
+		Shifts: []Shift{
+			{Name: "dzienna", Start: at(6, 0)},
+			{Name: "nocna", Start: at(22, 0)},
+		},
+	}
+)
+
+func TestScheduleIsOpen(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule ScheduleConfig
+		tod      time.Duration
+		open     bool
+	}{
+		{"dzienna przed otwarciem", daySchedule, at(5, 0) - time.Nanosecond, false},
+		{"dzienna w chwili otwarcia", daySchedule, at(5, 0), true},
+		{"dzienna tuż przed zamknięciem", daySchedule, at(23, 0) - time.Nanosecond, true},
+		{"dzienna w chwili zamknięcia", daySchedule, at(23, 0), false},
+		{"dzienna o północy", daySchedule, 0, false},
+		{"przez północ wieczorem", overnightSchedule, at(23, 30), true},
+		{"przez północ po północy", overnightSchedule, at(0, 59), true},
+		{"przez północ w chwili zamknięcia", overnightSchedule, at(1, 0), false},
+		{"przez północ nad ranem", overnightSchedule, at(4, 59), false},
+		{"przez północ w chwili otwarcia", overnightSchedule, at(5, 0), true},
+		{"całodobowa", ScheduleConfig{Open: at(6, 0), Close: at(6, 0)}, at(3, 0), true},
+	}
+	for _, tt := range tests {
+		if got := tt.schedule.isOpen(tt.tod); got != tt.open {
+			t.Errorf("%s (%s): isOpen = %v, oczekiwano %v", tt.name, formatTimeOfDay(tt.tod), got, tt.open)
+		}
+	}
+}
+
+func TestScheduleShiftAt(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule ScheduleConfig
+		tod      time.Duration
+		shift    int
+	}{
+		{"bez zmian", ScheduleConfig{}, at(12, 0), -1},
+		{"początek pierwszej zmiany", daySchedule, at(5, 0), 0},
+		{"tuż przed drugą zmianą", daySchedule, at(14, 0) - time.Nanosecond, 0},
+		{"początek drugiej zmiany", daySchedule, at(14, 0), 1},
+		{"ostatnia zmiana wieczorem", daySchedule, at(23, 30), 2},
+		{"ostatnia zmiana trwa po północy", daySchedule, at(3, 0), 2},
+		{"nocna po północy", overnightSchedule, 0, 1},
+		{"nocna tuż przed dzienną", overnightSchedule, at(6, 0) - time.Nanosecond, 1},
+		{"dzienna", overnightSchedule, at(6, 0), 0},
+		{"nocna wieczorem", overnightSchedule, at(22, 0), 1},
+	}
+	for _, tt := range tests {
+		if got := tt.schedule.shiftAt(tt.tod); got != tt.shift {
+			t.Errorf("%s (%s): shiftAt = %d, oczekiwano %d", tt.name, formatTimeOfDay(tt.tod), got, tt.shift)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule ScheduleConfig
+		tod      time.Duration
+		wait     time.Duration
+	}{
+		{"do otwarcia", daySchedule, at(4, 0), time.Hour},
+		// Granica w chwili tod już minęła: następna jest kolejna
+		{"od otwarcia do drugiej zmiany", daySchedule, at(5, 0), 9 * time.Hour},
+		{"do zamknięcia", daySchedule, at(22, 30), 30 * time.Minute},
+		{"od zamknięcia do otwarcia następnego dnia", daySchedule, at(23, 0), 6 * time.Hour},
+		{"od północy do otwarcia", daySchedule, 0, 5 * time.Hour},
+		{"przez północ do zamknięcia", overnightSchedule, at(22, 0), 3 * time.Hour},
+		{"przez północ od zamknięcia do otwarcia", overnightSchedule, at(1, 0), 4 * time.Hour},
+		{"przez północ od otwarcia do dziennej zmiany", overnightSchedule, at(5, 0), time.Hour},
+		{"całodobowa z jedną zmianą", ScheduleConfig{Shifts: []Shift{{Start: at(8, 0)}}}, at(8, 0), 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := tt.schedule.next(tt.tod); got != tt.wait {
+			t.Errorf("%s (%s): next = %v, oczekiwano %v", tt.name, formatTimeOfDay(tt.tod), got, tt.wait)
+		}
+	}
+}
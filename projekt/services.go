@@ -0,0 +1,317 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ShopConfig opisuje sklep stacji. Share kierowców po tankowaniu idzie
+// na zakupy: przez Browse wybiera towar za Basket PLN, po czym staje
+// w kolejce do tych samych kas co płacący za paliwo (Checkout - czas
+// obsługi przy kasie).
+type ShopConfig struct {
+	Share                float64
+	MinBrowse, MaxBrowse time.Duration
+	MinBasket, MaxBasket float64
+	Checkout             time.Duration
+}
+
+// WashConfig opisuje myjnię: Bays stanowisk, na które trafia Share
+// kierowców pojazdów Vehicles. Mycie trwa od MinDuration do MaxDuration
+// i kosztuje Price; kierowca nie czeka w kolejce dłuższej niż
+// QueueTolerance.
+type WashConfig struct {
+	Share                    float64
+	Bays                     int
+	Vehicles                 []VehicleType
+	MinDuration, MaxDuration time.Duration
+	Price                    float64
+	QueueTolerance           int
+}
+
+// StageStatistics przechowuje statystyki etapu obsługi po tankowaniu
+// (sklepu albo myjni)
+type StageStatistics struct {
+	Arrivals    int
+	Served      int
+	Skipped     int // zrezygnowali, widząc zbyt długą kolejkę
+	Revenue     float64
+	ServiceTime time.Duration // łączny czas obsługi
+	MaxQueue    int
+	Wait        Histogram // czekanie w kolejce etapu
+}
+
+// Throughput zwraca liczbę obsłużonych klientów na godzinę czasu elapsed.
+// Wywoływana z zablokowanym mutexem statystyk.
+func (s *StageStatistics) Throughput(elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Served) / elapsed.Hours()
+}
+
+// washJob to pojazd czekający na stanowisko myjni
+type washJob struct {
+	vehicle  *Vehicle
+	duration time.Duration
+	queuedAt time.Time
+	then     func(queueWait time.Duration) // po umyciu
+}
+
+// CarWash to myjnia: kolejka pojazdów obsługiwana przez Bays stanowisk,
+// każde we własnej goroutine (runBay). Pojazd wjeżdża do kolejki bez
+// czekania (Enter), a po umyciu stanowisko wywołuje jego then.
+type CarWash struct {
+	Bays    int
+	clock   Clock
+	queue   []*washJob
+	busy    int
+	closed  bool
+	mutex   sync.Mutex
+	arrived Cond // stanowiska czekają na pojazdy
+	wg      sync.WaitGroup
+}
+
+// NewCarWash tworzy myjnię z bays stanowiskami
+func NewCarWash(clock Clock, bays int) *CarWash {
+	w := &CarWash{Bays: bays, clock: clock}
+	w.arrived = clock.NewCond(&w.mutex)
+	return w
+}
+
+// Start uruchamia goroutines stanowisk
+func (w *CarWash) Start() {
+	for range w.Bays {
+		w.wg.Add(1)
+		w.clock.Go(w.runBay)
+	}
+}
+
+// runBay myje kolejne pojazdy z kolejki
+func (w *CarWash) runBay() {
+	defer w.wg.Done()
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	for {
+		for len(w.queue) == 0 && !w.closed {
+			w.arrived.Wait()
+		}
+		if len(w.queue) == 0 {
+			return
+		}
+
+		job := w.queue[0]
+		w.queue = w.queue[1:]
+		wait := w.clock.Since(job.queuedAt)
+		w.busy++
+		w.mutex.Unlock()
+
+		w.clock.Sleep(job.duration)
+		job.then(wait)
+
+		w.mutex.Lock()
+		w.busy--
+	}
+}
+
+// Enter ustawia pojazd w kolejce do myjni, jeśli czeka w niej najwyżej
+// tolerance pojazdów. Zwraca, czy pojazd dołączył, i długość kolejki.
+func (w *CarWash) Enter(job *washJob, tolerance int) (bool, int) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed || len(w.queue) > tolerance {
+		return false, len(w.queue)
+	}
+	job.queuedAt = w.clock.Now()
+	w.queue = append(w.queue, job)
+	w.arrived.Signal()
+	return true, len(w.queue)
+}
+
+// Status zwraca liczbę zajętych stanowisk i pojazdów w kolejce
+func (w *CarWash) Status() (busy, waiting int) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.busy, len(w.queue)
+}
+
+// Close zamyka myjnię i czeka na stanowiska. Wywoływana po zakończeniu
+// wszystkich wizyt, więc kolejka jest pusta.
+func (w *CarWash) Close() {
+	w.mutex.Lock()
+	w.closed = true
+	w.arrived.Broadcast()
+	w.mutex.Unlock()
+
+	w.clock.Await(w.wg.Wait)
+}
+
+// Przesunięcie ziarna generatora wizyt w sklepie i myjni
+const servicesSeedOffset = -1000
+
+// visitPlan to wylosowana dalsza droga kierowcy po tankowaniu
+type visitPlan struct {
+	shop     bool
+	browse   time.Duration
+	basket   float64 // PLN
+	wash     bool
+	washTime time.Duration
+}
+
+// planVisit losuje, czy kierowca idzie do sklepu i do myjni
+func (gs *GasStation) planVisit(vehicle *Vehicle) visitPlan {
+	shop, wash := gs.Scenario.Shop, gs.Scenario.Wash
+
+	gs.serviceMutex.Lock()
+	defer gs.serviceMutex.Unlock()
+
+	var plan visitPlan
+	rng := gs.serviceRng
+	if rng.Float64() < shop.Share {
+		plan.shop = true
+		plan.browse = shop.MinBrowse + time.Duration(rng.Int63n(int64(shop.MaxBrowse-shop.MinBrowse)+1))
+		plan.basket = shop.MinBasket + rng.Float64()*(shop.MaxBasket-shop.MinBasket)
+	}
+	if slices.Contains(wash.Vehicles, vehicle.Type) && rng.Float64() < wash.Share {
+		plan.wash = true
+		plan.washTime = wash.MinDuration + time.Duration(rng.Int63n(int64(wash.MaxDuration-wash.MinDuration)+1))
+	}
+	return plan
+}
+
+// continueVisit wysyła obsłużony pojazd do sklepu, a potem do myjni,
+// według wylosowanego planu. Dystrybutor jest już wolny: pojazd przechodzi
+// między etapami przez ich kolejki, a każdy etap obsługuje własna pula
+// goroutines (kasjerzy, stanowiska myjni). Stop czeka na koniec wszystkich
+// wizyt (visitWg).
+func (gs *GasStation) continueVisit(vehicle *Vehicle) {
+	if !gs.servicesEnabled() {
+		return
+	}
+	plan := gs.planVisit(vehicle)
+	switch {
+	case plan.shop:
+		gs.visitWg.Add(1)
+		gs.Clock.AfterFunc(plan.browse, func() { gs.enterShop(vehicle, plan) })
+	case plan.wash:
+		gs.visitWg.Add(1)
+		gs.enterWash(vehicle, plan)
+	}
+}
+
+// enterShop ustawia kierowcę z zakupami w kolejce do kas
+func (gs *GasStation) enterShop(vehicle *Vehicle, plan visitPlan) {
+	service := gs.Scenario.Shop.Checkout
+	queued := gs.Checkout.Buy(vehicle, service, func(wait time.Duration) {
+		gs.Stats.mutex.Lock()
+		shop := gs.Stats.Shop
+		shop.Served++
+		shop.Revenue += plan.basket
+		shop.ServiceTime += gs.serviceTime(service)
+		shop.Wait.Record(wait)
+		gs.Stats.mutex.Unlock()
+		gs.publish(EventShopPaid, vehicle, Event{Cost: plan.basket})
+
+		if plan.wash {
+			gs.enterWash(vehicle, plan)
+		} else {
+			gs.visitWg.Done()
+		}
+	})
+
+	gs.Stats.mutex.Lock()
+	gs.Stats.Shop.Arrivals++
+	gs.Stats.Shop.MaxQueue = max(gs.Stats.Shop.MaxQueue, queued)
+	gs.Stats.mutex.Unlock()
+}
+
+// enterWash ustawia pojazd w kolejce do myjni; przy zbyt długiej kolejce
+// kierowca odjeżdża bez mycia
+func (gs *GasStation) enterWash(vehicle *Vehicle, plan visitPlan) {
+	price := gs.Scenario.Wash.Price
+	job := &washJob{
+		vehicle:  vehicle,
+		duration: plan.washTime,
+		then: func(wait time.Duration) {
+			gs.Stats.mutex.Lock()
+			wash := gs.Stats.Wash
+			wash.Served++
+			wash.Revenue += price
+			wash.ServiceTime += gs.serviceTime(plan.washTime)
+			wash.Wait.Record(wait)
+			gs.Stats.mutex.Unlock()
+			gs.publish(EventWashed, vehicle, Event{Cost: price})
+			gs.visitWg.Done()
+		},
+	}
+	queued, length := gs.Wash.Enter(job, gs.Scenario.Wash.QueueTolerance)
+
+	gs.Stats.mutex.Lock()
+	gs.Stats.Wash.Arrivals++
+	if queued {
+		gs.Stats.Wash.MaxQueue = max(gs.Stats.Wash.MaxQueue, length)
+	} else {
+		gs.Stats.Wash.Skipped++
+	}
+	gs.Stats.mutex.Unlock()
+
+	if !queued {
+		gs.visitWg.Done()
+	}
+}
+
+// serviceTime zwraca część właśnie zakończonej obsługi trwającej d, która
+// przypada na czas pracy stacji - obsługa dokończona po zatrzymaniu nie
+// wlicza się do obciążenia (jak przy dystrybutorach)
+func (gs *GasStation) serviceTime(d time.Duration) time.Duration {
+	end := gs.Clock.Now()
+	start := end.Add(-d)
+	gs.mutex.RLock()
+	if !gs.Running {
+		end = gs.StopTime
+	}
+	gs.mutex.RUnlock()
+	return max(end.Sub(start), 0)
+}
+
+// servicesEnabled sprawdza, czy kierowcy chodzą do sklepu albo myjni
+func (gs *GasStation) servicesEnabled() bool {
+	return gs.Scenario.Shop.Share > 0 || gs.Scenario.Wash.Share > 0
+}
+
+// printServices wypisuje w podsumowaniu przepustowość, czekanie
+// i przychód sklepu i myjni. Wywoływana z zablokowanym mutexem statystyk.
+func printServices(gs *GasStation, elapsed time.Duration) {
+	fmt.Println("\nUsługi dodatkowe:")
+	fmt.Println("  Etap     Wejścia  Obsłużeni  Zrezygnowali  Na godzinę  Śr. czekanie  p90 czekania  Maks. kolejka  Obciążenie  Przychód [PLN]")
+	for _, stage := range []struct {
+		name    string
+		stats   *StageStatistics
+		servers int
+	}{
+		{"Sklep", gs.Stats.Shop, gs.Checkout.NumCashiers},
+		{"Myjnia", gs.Stats.Wash, gs.Wash.Bays},
+	} {
+		s := stage.stats
+		avgWait := time.Duration(0)
+		if n := s.Wait.Count(); n > 0 {
+			avgWait = s.Wait.Sum() / time.Duration(n)
+		}
+		load := 0.0
+		if elapsed > 0 {
+			load = float64(s.ServiceTime) / float64(elapsed*time.Duration(stage.servers))
+		}
+		fmt.Printf("  %-7s  %7d  %9d  %12d  %10.1f  %12v  %12v  %13d  %9.1f%%  %14.2f\n",
+			stage.name, s.Arrivals, s.Served, s.Skipped, s.Throughput(elapsed),
+			avgWait.Round(10*time.Millisecond), s.Wait.Quantile(0.9).Round(10*time.Millisecond),
+			s.MaxQueue, 100*load, s.Revenue)
+	}
+	ancillary := gs.Stats.Shop.Revenue + gs.Stats.Wash.Revenue
+	fmt.Printf("  Przychód dodatkowy: %.2f PLN (%.1f%% przychodu z paliwa)\n",
+		ancillary, 100*share(ancillary, gs.Stats.TotalRevenue))
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "nadpisz pliki wzorcowe w testdata")
+
+// runVirtual przeprowadza scenariusz s w czasie wirtualnym i zwraca
+// zatrzymaną stację
+func runVirtual(s Scenario) *GasStation {
+	clock := NewVirtualClock(simulationStart)
+	station := NewGasStation(clock, &s)
+	station.Headless = true
+	station.Start(context.Background())
+	clock.Sleep(s.Duration)
+	station.Stop(StopDrain)
+	return station
+}
+
+// captureStdout zwraca to, co f wypisało na standardowe wyjście
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	out := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		out <- string(b)
+	}()
+	f()
+	w.Close()
+	return <-out
+}
+
+// TestSummaryGolden porównuje podsumowanie (z raportem M/M/c) dwóch
+// godzin wbudowanego scenariusza z ziarnem 42 z testdata/summary.golden.
+// Po zamierzonej zmianie wyników plik odświeża go test -run Golden -update.
+func TestSummaryGolden(t *testing.T) {
+	s := *DefaultScenario()
+	s.Seed = 42
+	s.Duration = 2 * time.Hour
+	station := runVirtual(s)
+	got := captureStdout(t, func() { printSummary(station, true) })
+
+	golden := filepath.Join("testdata", "summary.golden")
+	if *update {
+		if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("podsumowanie różni się od %s:\n%s", golden, got)
+	}
+}
+
+func TestVirtualClockDeterministic(t *testing.T) {
+	s := *DefaultScenario()
+	s.Seed = 42
+	s.Duration = 6 * time.Hour
+
+	first, second := runVirtual(s), runVirtual(s)
+	if first.Stats.ServedVehicles == 0 {
+		t.Fatal("żaden pojazd nie został obsłużony")
+	}
+	if !reflect.DeepEqual(first.Stats, second.Stats) {
+		t.Error("statystyki dwóch przebiegów z tym samym ziarnem się różnią")
+	}
+
+	summaries := [2]string{}
+	for i, station := range []*GasStation{first, second} {
+		summaries[i] = captureStdout(t, func() { printSummary(station, true) })
+	}
+	if summaries[0] != summaries[1] {
+		t.Errorf("podsumowania dwóch przebiegów się różnią:\n%s\n---\n%s", summaries[0], summaries[1])
+	}
+}
+
+// BenchmarkVirtualDay mierzy dobę wbudowanego scenariusza w czasie wirtualnym
+func BenchmarkVirtualDay(b *testing.B) {
+	s := *DefaultScenario()
+	s.Seed = 42
+	s.Duration = 24 * time.Hour
+	for b.Loop() {
+		runVirtual(s)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Parametry cysterny
+const (
+	tankerTravelTime = 10 * time.Second
+	tankerUnloadTime = 3 * time.Second
+)
+
+// Tank reprezentuje podziemny zbiornik na jeden rodzaj paliwa
+type Tank struct {
+	FuelType      FuelType
+	Capacity      float64 // litry
+	Level         float64 // litry
+	RefillLevel   float64 // próg zamówienia cysterny
+	Unloading     bool    // cysterna się rozładowuje, dystrybutory tego paliwa stoją
+	refillOrdered bool
+	closed        bool
+	tanker        *Tanker
+	mutex         sync.Mutex
+	cond          Cond
+}
+
+// NewTank tworzy pełny zbiornik, który zamawia dostawy u cysterny tanker
+func NewTank(clock Clock, fuelType FuelType, capacity, refillLevel float64, tanker *Tanker) *Tank {
+	t := &Tank{
+		FuelType:    fuelType,
+		Capacity:    capacity,
+		Level:       capacity,
+		RefillLevel: refillLevel,
+		tanker:      tanker,
+	}
+	t.cond = clock.NewCond(&t.mutex)
+	return t
+}
+
+// fill zwraca poziom paliwa jako ułamek pojemności zbiornika
+func (t *Tank) fill() float64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.Level / t.Capacity
+}
+
+// Take pobiera amount litrów ze zbiornika. W trakcie rozładunku cysterny
+// czeka, aż się zakończy. Gdy paliwa jest za mało, zgłasza brak (stockOut)
+// i - jeśli wait jest ustawione - czeka na dostawę. ok == false oznacza,
+// że pojazd odjechał bez paliwa. Więcej niż pojemność zbiornika nie da
+// się pobrać nawet po dostawie, więc taki pojazd odjeżdża od razu.
+func (t *Tank) Take(amount float64, wait bool) (ok, stockOut bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if amount > t.Capacity {
+		return false, false
+	}
+	for t.Unloading || t.Level < amount {
+		if t.closed {
+			return false, stockOut
+		}
+		if !t.Unloading {
+			// Dostawę mogły już wybrać inne pojazdy, a poziom nad progiem
+			// zamówienia nie wezwie cysterny - zamawiamy ją przy każdym
+			// braku (orderRefill pomija powtórne zamówienia)
+			t.orderRefill()
+			if !stockOut {
+				stockOut = true
+				if !wait {
+					return false, stockOut
+				}
+			}
+		}
+		t.cond.Wait()
+	}
+
+	t.Level -= amount
+	if t.Level < t.RefillLevel {
+		t.orderRefill()
+	}
+	return true, stockOut
+}
+
+// Return oddaje do zbiornika paliwo, którego nie zdążono wydać
+func (t *Tank) Return(amount float64) {
+	t.mutex.Lock()
+	t.Level = min(t.Level+amount, t.Capacity)
+	t.cond.Broadcast()
+	t.mutex.Unlock()
+}
+
+// orderRefill zamawia cysternę, o ile nie jest już w drodze.
+// Wywoływana z zablokowanym mutexem zbiornika.
+func (t *Tank) orderRefill() {
+	if t.refillOrdered {
+		return
+	}
+	t.refillOrdered = true
+	t.tanker.Order(t)
+}
+
+// startUnloading blokuje dystrybutory tego paliwa na czas rozładunku cysterny
+func (t *Tank) startUnloading() {
+	t.mutex.Lock()
+	t.Unloading = true
+	t.mutex.Unlock()
+}
+
+// finishUnloading kończy rozładunek, napełniając zbiornik
+func (t *Tank) finishUnloading() {
+	t.mutex.Lock()
+	t.Level = t.Capacity
+	t.Unloading = false
+	t.refillOrdered = false
+	t.cond.Broadcast()
+	t.mutex.Unlock()
+}
+
+// close budzi wszystkie pojazdy czekające na paliwo przy zatrzymaniu stacji
+func (t *Tank) close() {
+	t.mutex.Lock()
+	t.closed = true
+	t.cond.Broadcast()
+	t.mutex.Unlock()
+}
+
+// Tanker reprezentuje cysternę realizującą zamówienia zbiorników po kolei
+type Tanker struct {
+	orders []*Tank
+	closed bool
+	mutex  sync.Mutex
+	cond   Cond
+}
+
+// NewTanker tworzy cysternę bez zamówień
+func NewTanker(clock Clock) *Tanker {
+	t := &Tanker{}
+	t.cond = clock.NewCond(&t.mutex)
+	return t
+}
+
+// Order dopisuje zbiornik do listy zamówień cysterny
+func (t *Tanker) Order(tank *Tank) {
+	t.mutex.Lock()
+	t.orders = append(t.orders, tank)
+	t.cond.Signal()
+	t.mutex.Unlock()
+}
+
+// next czeka na kolejne zamówienie; zwraca nil po zamknięciu
+func (t *Tanker) next() *Tank {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for len(t.orders) == 0 && !t.closed {
+		t.cond.Wait()
+	}
+	if t.closed {
+		return nil
+	}
+	tank := t.orders[0]
+	t.orders = t.orders[1:]
+	return tank
+}
+
+// Close kończy pracę cysterny przy zatrzymaniu stacji
+func (t *Tanker) Close() {
+	t.mutex.Lock()
+	t.closed = true
+	t.cond.Broadcast()
+	t.mutex.Unlock()
+}
+
+// runTanker obsługuje cysternę dowożącą paliwo do zbiorników
+func (gs *GasStation) runTanker() {
+	for {
+		tank := gs.Tanker.next()
+		if tank == nil {
+			break
+		}
+
+		// Dojazd cysterny (przerywany przy zatrzymaniu stacji), potem rozładunek
+		if !gs.pause(tankerTravelTime) {
+			break
+		}
+		tank.startUnloading()
+		gs.Clock.Sleep(tankerUnloadTime)
+		tank.finishUnloading()
+
+		gs.Stats.mutex.Lock()
+		gs.Stats.Fuel[tank.FuelType].Refills++
+		gs.Stats.mutex.Unlock()
+	}
+}
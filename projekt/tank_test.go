@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestTank tworzy pełny zbiornik diesla o pojemności capacity na
+// zegarze wirtualnym
+func newTestTank(capacity float64) (*Tank, *Tanker, *VirtualClock) {
+	clock := NewVirtualClock(simulationStart)
+	tanker := NewTanker(clock)
+	return NewTank(clock, Diesel, capacity, capacity/4, tanker), tanker, clock
+}
+
+func TestTakeMoreThanCapacity(t *testing.T) {
+	tank, tanker, _ := newTestTank(100)
+
+	// Bez limitu pojazd czekałby na dostawę bez końca: po niej Level ==
+	// Capacity < amount. Take musi odmówić od razu, nawet z wait.
+	ok, stockOut := tank.Take(150, true)
+	if ok || stockOut {
+		t.Fatalf("Take(150) przy pojemności 100 = (%v, %v), oczekiwano (false, false)", ok, stockOut)
+	}
+	if tank.Level != 100 {
+		t.Errorf("poziom po odmowie = %v, oczekiwano 100", tank.Level)
+	}
+	if len(tanker.orders) != 0 {
+		t.Errorf("odmowa zamówiła cysternę (%d zamówień)", len(tanker.orders))
+	}
+}
+
+func TestTake(t *testing.T) {
+	tank, tanker, _ := newTestTank(100)
+
+	if ok, stockOut := tank.Take(100, false); !ok || stockOut {
+		t.Fatalf("Take(100) z pełnego zbiornika = (%v, %v), oczekiwano (true, false)", ok, stockOut)
+	}
+	if tank.Level != 0 {
+		t.Errorf("poziom = %v, oczekiwano 0", tank.Level)
+	}
+	if len(tanker.orders) != 1 {
+		t.Errorf("zamówienia cysterny = %d, oczekiwano 1", len(tanker.orders))
+	}
+
+	// Brak paliwa bez czekania: pojazd odjeżdża, cysterna już jedzie
+	if ok, stockOut := tank.Take(10, false); ok || !stockOut {
+		t.Errorf("Take(10) z pustego zbiornika = (%v, %v), oczekiwano (false, true)", ok, stockOut)
+	}
+	if len(tanker.orders) != 1 {
+		t.Errorf("zamówienia cysterny = %d, oczekiwano 1", len(tanker.orders))
+	}
+}
+
+func TestTakeReordersAfterDrainedDelivery(t *testing.T) {
+	tank, tanker, clock := newTestTank(100)
+	tank.Level = 0
+
+	// Dwa pojazdy czekają na dostawę; pierwszy obsłużony zostawia w zbiorniku
+	// 40-50 L - powyżej progu zamówienia (25 L), ale za mało dla drugiego
+	var served [2]bool
+	for i, amount := range []float64{60, 50} {
+		clock.Go(func() { served[i], _ = tank.Take(amount, true) })
+	}
+	deliver := func() {
+		t.Helper()
+		clock.Sleep(time.Second)
+		if len(tanker.orders) != 1 {
+			t.Fatalf("zamówienia cysterny = %d, oczekiwano 1", len(tanker.orders))
+		}
+		tanker.next()
+		tank.startUnloading()
+		tank.finishUnloading()
+		clock.Sleep(time.Second)
+	}
+
+	deliver()
+	if served[0] == served[1] {
+		t.Fatalf("po pierwszej dostawie obsłużone: %v, oczekiwano jednego pojazdu", served)
+	}
+	deliver()
+	if !served[0] || !served[1] {
+		t.Errorf("po drugiej dostawie obsłużone: %v, oczekiwano obu pojazdów", served)
+	}
+}
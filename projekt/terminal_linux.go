@@ -0,0 +1,9 @@
+package main
+
+import "syscall"
+
+// Odczyt i zapis ustawień terminala
+const (
+	ioctlGetTermios = syscall.TCGETS
+	ioctlSetTermios = syscall.TCSETS
+)
@@ -0,0 +1,17 @@
+//go:build !darwin && !freebsd && !linux && !netbsd && !openbsd
+
+package main
+
+import "errors"
+
+// makeRaw nie jest dostępne na tym systemie - klawisze trafiają do
+// interfejsu dopiero po Enter
+func makeRaw(fd uintptr) (restore func(), err error) {
+	return nil, errors.ErrUnsupported
+}
+
+// terminalSize nie jest dostępne na tym systemie - interfejs przyjmuje
+// domyślny rozmiar
+func terminalSize(fd uintptr) (width, height int, err error) {
+	return 0, 0, errors.ErrUnsupported
+}
@@ -0,0 +1,552 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+)
+
+// Parametry interfejsu terminalowego
+const (
+	uiRefresh       = 250 * time.Millisecond
+	minSpeed        = 0.125 // najwolniejsze i najszybsze tempo zegara
+	maxSpeed        = 64
+	defaultWidth    = 80 // gdy nie da się odczytać rozmiaru terminala
+	pumpCellWidth   = 40
+	progressWidth   = 20
+	pumpBarWidth    = 12 // pasek tankowania, obok litrów i kwoty
+	maxQueueShown   = 40 // pojazdy pokazane w wizualizacji jednej kolejki
+	statusLogPeriod = 5 * time.Second
+)
+
+// Litery pojazdów w wizualizacji kolejek
+var vehicleGlyphs = map[VehicleType]string{
+	Car:        "S",
+	Truck:      "C",
+	Motorcycle: "M",
+	Emergency:  "U",
+}
+
+// Powody odjazdu bez obsługi w logu zdarzeń
+var leftReasonTexts = map[string]string{
+	LeftNoPump:     "żaden dystrybutor nie sprzedaje jego paliwa",
+	LeftBalked:     "zbyt długa kolejka",
+	LeftReneged:    "koniec cierpliwości",
+	LeftStockOut:   "brak paliwa",
+	LeftTurnedAway: "stacja zamknięta",
+	LeftPrice:      "zbyt wysoka cena",
+	LeftClosed:     "stacja poza godzinami otwarcia",
+}
+
+// Powody zakończenia tankowania przed wydaniem zamówionego paliwa w logu zdarzeń
+var stopReasonTexts = map[string]string{
+	StoppedMoneyLimit: "limit kwoty",
+	StoppedShutdown:   "zatrzymanie stacji",
+}
+
+// screen rysuje klatki interfejsu sekwencjami ANSI. Pamięta poprzednią
+// klatkę i przepisuje tylko wiersze, które się zmieniły, więc obraz nie
+// miga, a do terminala trafia niewiele danych.
+type screen struct {
+	out    *bufio.Writer
+	lines  []string
+	width  int
+	height int // 0 - nieznana
+}
+
+func newScreen(w io.Writer) *screen {
+	return &screen{out: bufio.NewWriter(w)}
+}
+
+// open przełącza terminal na osobny bufor ekranu i ukrywa kursor
+func (s *screen) open() {
+	s.out.WriteString("\x1b[?1049h\x1b[?25l\x1b[2J")
+	s.out.Flush()
+}
+
+// close przywraca kursor i główny bufor ekranu z jego dawną zawartością
+func (s *screen) close() {
+	s.out.WriteString("\x1b[?25h\x1b[?1049l")
+	s.out.Flush()
+}
+
+// resize ustawia rozmiar ekranu; po zmianie następna klatka jest
+// rysowana od nowa
+func (s *screen) resize(width, height int) {
+	if width == s.width && height == s.height {
+		return
+	}
+	s.width, s.height = width, height
+	s.lines = nil
+	s.out.WriteString("\x1b[2J")
+}
+
+// draw rysuje klatkę, przepisując tylko zmienione wiersze. Wiersze
+// szersze od ekranu są przycinane, a te poniżej jego dolnej krawędzi
+// pomijane, żeby terminal nie przewinął obrazu.
+func (s *screen) draw(frame []string) {
+	if s.height > 0 && len(frame) > s.height {
+		frame = frame[:s.height]
+	}
+	for i, line := range frame {
+		line = truncate(line, s.width-1)
+		frame[i] = line
+		if i < len(s.lines) && s.lines[i] == line {
+			continue
+		}
+		fmt.Fprintf(s.out, "\x1b[%d;1H%s\x1b[K", i+1, line)
+	}
+	if len(frame) < len(s.lines) {
+		fmt.Fprintf(s.out, "\x1b[%d;1H\x1b[J", len(frame)+1)
+	}
+	s.lines = frame
+	s.out.Flush()
+}
+
+// truncate skraca napis do width znaków
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if width < 0 || len(runes) <= width {
+		return s
+	}
+	return string(runes[:width])
+}
+
+// isTerminal sprawdza, czy plik jest terminalem, na którym można rysować
+// interfejs
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	// Urządzenia znakowe takie jak /dev/null nie mają rozmiaru okna
+	_, _, err = terminalSize(f.Fd())
+	return err == nil || errors.Is(err, errors.ErrUnsupported)
+}
+
+// displayUI rysuje interfejs terminalowy i wykonuje polecenia z
+// klawiatury aż do zatrzymania stacji. Przy wyjściu wznawia wstrzymany
+// zegar, żeby stacja mogła dokończyć obsługę.
+func (gs *GasStation) displayUI(ctx context.Context) {
+	scr := newScreen(os.Stdout)
+	scr.open()
+	defer scr.close()
+	if restore, err := makeRaw(os.Stdin.Fd()); err == nil {
+		defer restore()
+	}
+	if clock, ok := gs.Clock.(*ScaledClock); ok {
+		defer clock.SetPaused(false)
+	}
+
+	keys := make(chan byte, 16)
+	go readKeys(os.Stdin, keys)
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	ticker := time.NewTicker(uiRefresh)
+	defer ticker.Stop()
+
+	message := ""
+	for {
+		width, height, err := terminalSize(os.Stdout.Fd())
+		if err != nil {
+			width, height = defaultWidth, 0
+		}
+		scr.resize(width, height)
+		scr.draw(gs.frame(width, message))
+
+		select {
+		case <-ctx.Done():
+			return
+		case key := <-keys:
+			if m := gs.handleKey(key, rng); m != "" {
+				message = m
+			}
+		case <-ticker.C:
+		}
+	}
+}
+
+// readKeys przekazuje naciśnięte klawisze do keys. Odczytu ze
+// standardowego wejścia nie da się przerwać, więc ta goroutine nie jest
+// śledzona przez wg - kończy się razem z programem.
+func readKeys(in io.Reader, keys chan<- byte) {
+	buf := make([]byte, 16)
+	for {
+		n, err := in.Read(buf)
+		for _, b := range buf[:n] {
+			select {
+			case keys <- b:
+			default:
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// handleKey wykonuje polecenie przypisane do klawisza i zwraca komunikat
+// dla użytkownika (pusty dla nieznanego klawisza)
+func (gs *GasStation) handleKey(key byte, rng *rand.Rand) string {
+	clock, scaled := gs.Clock.(*ScaledClock)
+	switch {
+	case key == 'p' || key == ' ':
+		if !scaled {
+			return "Tego zegara nie można wstrzymać"
+		}
+		_, paused := clock.Speed()
+		clock.SetPaused(!paused)
+		if paused {
+			return "Symulacja wznowiona"
+		}
+		return "Symulacja wstrzymana"
+	case key == '+' || key == '=' || key == '-':
+		if !scaled {
+			return "Tempa tego zegara nie można zmienić"
+		}
+		speed, _ := clock.Speed()
+		if key == '-' {
+			speed = max(speed/2, minSpeed)
+		} else {
+			speed = min(speed*2, maxSpeed)
+		}
+		clock.SetSpeed(speed)
+		return "Tempo symulacji: " + formatSpeed(speed)
+	case key == 'a':
+		return gs.addRandomVehicle(rng)
+	case key >= '1' && key <= '9':
+		return gs.togglePump(int(key - '0'))
+	case key == 'q':
+		if gs.Quit != nil {
+			gs.Quit()
+			return "Zatrzymywanie stacji..."
+		}
+	}
+	return ""
+}
+
+// addRandomVehicle dodaje pojazd wylosowany z jednego ze strumieni
+// przyjazdów scenariusza
+func (gs *GasStation) addRandomVehicle(rng *rand.Rand) string {
+	profiles := gs.Scenario.Vehicles
+	if len(profiles) == 0 {
+		return "Scenariusz nie opisuje żadnych pojazdów"
+	}
+	vehicle := profiles[rng.Intn(len(profiles))].NewVehicle(rng)
+	if !gs.AddVehicle(vehicle) {
+		return fmt.Sprintf("Pojazd #%d (%s, %s) odjechał bez tankowania", vehicle.ID, vehicle.Type, vehicle.FuelType)
+	}
+	return fmt.Sprintf("Dodano pojazd #%d (%s, %s, %.1f L)", vehicle.ID, vehicle.Type, vehicle.FuelType, vehicle.FuelAmount)
+}
+
+// togglePump zamyka otwarty dystrybutor albo otwiera zamknięty
+func (gs *GasStation) togglePump(id int) string {
+	if id > len(gs.Pumps) {
+		return fmt.Sprintf("Nie ma dystrybutora %d", id)
+	}
+	pump := gs.Pumps[id-1]
+	pump.mutex.Lock()
+	closed := pump.Closed
+	pump.mutex.Unlock()
+
+	gs.SetPumpOpen(id, closed)
+	if closed {
+		return fmt.Sprintf("Dystrybutor %d otwarty", id)
+	}
+	return fmt.Sprintf("Dystrybutor %d zamknięty - dokończy obsługę bieżącego pojazdu", id)
+}
+
+// formatSpeed opisuje tempo zegara, np. "×4" albo "×0.25"
+func formatSpeed(speed float64) string {
+	return fmt.Sprintf("×%g", speed)
+}
+
+// frame buduje klatkę interfejsu dla ekranu szerokości width
+func (gs *GasStation) frame(width int, message string) []string {
+	var lines []string
+	add := func(format string, args ...any) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+
+	now := gs.Clock.Now()
+	state := ""
+	if clock, ok := gs.Clock.(*ScaledClock); ok {
+		speed, paused := clock.Speed()
+		state = "tempo " + formatSpeed(speed)
+		if paused {
+			state += "  [PAUZA]"
+		}
+	}
+	if schedule := gs.Scenario.Schedule; schedule.enabled() {
+		hours := "OTWARTA"
+		if !gs.isOpen() {
+			hours = "ZAMKNIĘTA"
+		}
+		if i := gs.currentShift(); i >= 0 {
+			hours += " (" + schedule.Shifts[i].Name + ")"
+		}
+		state = hours + "   " + state
+	}
+	add("SYMULACJA STACJI BENZYNOWEJ   %s (%v od startu)   %s",
+		now.Format("15:04:05"), now.Sub(gs.StartTime).Round(time.Second), state)
+	add("[p] pauza  [+/-] tempo  [a] nowy pojazd  [1-9] zamknij/otwórz dystrybutor  [q] koniec")
+	add("%s", message)
+	add("")
+
+	// Dystrybutory w siatce, tyle kolumn, ile mieści się na ekranie
+	add("DYSTRYBUTORY")
+	cells := make([][]string, len(gs.Pumps))
+	for i, pump := range gs.Pumps {
+		cells[i] = pumpCell(pump)
+	}
+	columns := max(1, width/pumpCellWidth)
+	for start := 0; start < len(cells); start += columns {
+		row := cells[start:min(start+columns, len(cells))]
+		for i := range row[0] {
+			var b strings.Builder
+			for _, cell := range row {
+				fmt.Fprintf(&b, "%-*s", pumpCellWidth, truncate(cell[i], pumpCellWidth-2))
+			}
+			add("%s", strings.TrimRight(b.String(), " "))
+		}
+	}
+	add("")
+
+	// Kolejki: litera na każdy czekający pojazd
+	add("KOLEJKI (%d)   S - samochód, C - ciężarówka, M - motocykl, U - uprzywilejowany", gs.Queue.Len())
+	for _, ft := range allFuelTypes {
+		waiting := gs.Queue.Waiting(ft)
+		add("  %-10s %3d  %s", ft, len(waiting), queueGlyphs(waiting))
+	}
+	busy, waiting := gs.Checkout.Status()
+	if onDuty := gs.Checkout.OnDuty(); onDuty < gs.Checkout.NumCashiers {
+		add("  %-10s %3d  %s  (zajęte: %d/%d, obsadzone %d z %d kas)", "Kasy", waiting, dots(waiting), busy, onDuty, onDuty, gs.Checkout.NumCashiers)
+	} else {
+		add("  %-10s %3d  %s  (zajęte: %d/%d)", "Kasy", waiting, dots(waiting), busy, gs.Checkout.NumCashiers)
+	}
+	if gs.servicesEnabled() {
+		washing, washQueue := gs.Wash.Status()
+		add("  %-10s %3d  %s  (zajęte: %d/%d)", "Myjnia", washQueue, dots(washQueue), washing, gs.Wash.Bays)
+	}
+	add("")
+
+	gs.Stats.mutex.RLock()
+	defer gs.Stats.mutex.RUnlock()
+
+	add("STATYSTYKI")
+	add("  Pojazdy łącznie:          %d", gs.Stats.TotalVehicles)
+	add("  Obsłużone pojazdy:        %d", gs.Stats.ServedVehicles)
+	add("  Zrezygnowali:             %d (na widok kolejki: %d, po czasie: %d)",
+		gs.Stats.BalkedVehicles+gs.Stats.RenegedVehicles, gs.Stats.BalkedVehicles, gs.Stats.RenegedVehicles)
+	add("  Zużyte paliwo:            %.2f L", gs.Stats.TotalFuelDispensed)
+	add("  Przychód:                 %.2f PLN", gs.Stats.TotalRevenue)
+	if gs.Stats.ServedVehicles > 0 {
+		add("  Średni czas oczekiwania:  %v", gs.Stats.AverageWaitTime.Round(time.Millisecond))
+	} else {
+		add("  Średni czas oczekiwania:  N/A")
+	}
+	add("  Blokada przez kolejkę:    %v", gs.Stats.PumpBlockedTime.Round(time.Millisecond))
+	if gs.servicesEnabled() {
+		add("  Sklep:                    zakupy: %d (%.2f PLN)", gs.Stats.Shop.Served, gs.Stats.Shop.Revenue)
+		add("  Myjnia:                   umyte: %d, zrezygnowali: %d (%.2f PLN)",
+			gs.Stats.Wash.Served, gs.Stats.Wash.Skipped, gs.Stats.Wash.Revenue)
+	}
+	if gs.failuresEnabled() {
+		working, jobs := gs.Crew.Status()
+		add("  Serwisanci pracujący:     %d/%d (zlecenia w kolejce: %d, przerwane tankowania: %d)",
+			working, gs.Crew.Size, jobs, gs.Stats.InterruptedRefuels)
+	}
+	if gs.pricingEnabled() {
+		prices := gs.Pricing.Prices()
+		var b strings.Builder
+		for _, ft := range allFuelTypes {
+			if price, ok := prices[ft]; ok {
+				fmt.Fprintf(&b, "  %s %.2f", ft, price)
+			}
+		}
+		add("  Ceny (%s):%s", gs.Pricing.Policy.Strategy, b.String())
+		add("  Odjechali przez cenę:     %d", gs.Stats.PriceBalked)
+	}
+	add("")
+
+	add("ZBIORNIKI")
+	for _, ft := range allFuelTypes {
+		tank := gs.Tanks[ft]
+		tank.mutex.Lock()
+		level, capacity, unloading := tank.Level, tank.Capacity, tank.Unloading
+		tank.mutex.Unlock()
+
+		status := ""
+		if unloading {
+			status = " [ROZŁADUNEK]"
+		}
+		fs := gs.Stats.Fuel[ft]
+		add("  %-10s %s %7.1f / %.0f L%s  braki: %d, dostawy: %d, utracone: %d",
+			ft, progressBar(level/capacity, progressWidth), level, capacity, status, fs.StockOuts, fs.Refills, fs.LostSales)
+	}
+	add("")
+
+	add("CZAS OCZEKIWANIA")
+	lines = append(lines, timingLines(gs.Stats, "  ", func(t *Timings) *Histogram { return &t.Wait })...)
+	return lines
+}
+
+// pumpCell opisuje dystrybutor w trzech wierszach: stan i pojazd,
+// paliwa oraz liczniki tankowania
+func pumpCell(pump *Pump) []string {
+	pump.mutex.Lock()
+	defer pump.mutex.Unlock()
+
+	status := "WOLNY"
+	switch {
+	case pump.Broken:
+		status = "AWARIA"
+	case pump.InMaintenance:
+		status = "PRZEGLĄD"
+	case pump.Paying:
+		status = "PŁATNOŚĆ"
+	case pump.IsOccupied:
+		status = "ZAJĘTY"
+	case pump.Closed:
+		status = "ZAMKNIĘTY"
+	case pump.OffShift:
+		status = "POZA ZMIANĄ"
+	}
+	cell := []string{
+		fmt.Sprintf("%d [%s]", pump.ID, status),
+		"  " + strings.Trim(fuelList(pump.FuelTypes), "()") + laneLabel(pump),
+		"",
+	}
+	if vehicle := pump.CurrentVehicle; vehicle != nil {
+		cell[0] += fmt.Sprintf(" #%d %s", vehicle.ID, vehicle.Type)
+		if vehicle.Order == OrderAmount {
+			// Zamówienie za kwotę: postęp według kwoty
+			cell[2] = fmt.Sprintf("  %s %5.1f L %.2f/%.0f zł",
+				progressBar(pump.CurrentCost/vehicle.MoneyLimit, pumpBarWidth),
+				pump.CurrentLiters, pump.CurrentCost, vehicle.MoneyLimit)
+		} else {
+			cell[2] = fmt.Sprintf("  %s %5.1f/%.0f L %.2f zł",
+				progressBar(pump.CurrentLiters/vehicle.FuelAmount, pumpBarWidth),
+				pump.CurrentLiters, vehicle.FuelAmount, pump.CurrentCost)
+		}
+	}
+	return cell
+}
+
+// progressBar rysuje pasek o szerokości width wypełniony w ułamku fraction
+func progressBar(fraction float64, width int) string {
+	filled := int(fraction*float64(width) + 0.5)
+	filled = min(max(filled, 0), width)
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+// queueGlyphs zamienia kolejkę na litery typów pojazdów; dłuższe kolejki
+// kończy liczba pominiętych pojazdów
+func queueGlyphs(waiting []VehicleType) string {
+	var b strings.Builder
+	for _, vt := range waiting[:min(len(waiting), maxQueueShown)] {
+		b.WriteString(vehicleGlyphs[vt])
+	}
+	if len(waiting) > maxQueueShown {
+		fmt.Fprintf(&b, " +%d", len(waiting)-maxQueueShown)
+	}
+	return b.String()
+}
+
+// dots rysuje kolejkę n osób
+func dots(n int) string {
+	s := strings.Repeat("•", min(n, maxQueueShown))
+	if n > maxQueueShown {
+		s += fmt.Sprintf(" +%d", n-maxQueueShown)
+	}
+	return s
+}
+
+// logEvents wypisuje zdarzenia stacji jako kolejne wiersze - interfejs
+// dla wyjścia, które nie jest terminalem (plik, potok)
+func (gs *GasStation) logEvents(ctx context.Context, sub *Subscription) {
+	defer gs.Events.Unsubscribe(sub)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if n := sub.TakeDropped(); n > 0 {
+				fmt.Printf("(pominięto %d zdarzeń)\n", n)
+			}
+			fmt.Println(formatEvent(e))
+		}
+	}
+}
+
+// formatEvent opisuje zdarzenie w jednym wierszu logu
+func formatEvent(e Event) string {
+	at := e.Time.Format("15:04:05.000")
+	fuel := fuelTypeNames[e.Fuel]
+	switch e.Kind {
+	case EventPriceChanged:
+		return fmt.Sprintf("%s  cena %s: %.2f PLN/L", at, fuel, e.Price)
+	case EventShiftStarted:
+		return fmt.Sprintf("%s  początek zmiany %s", at, e.Shift)
+	case EventStationOpened:
+		return fmt.Sprintf("%s  stacja otwarta", at)
+	case EventStationClosed:
+		return fmt.Sprintf("%s  stacja zamknięta", at)
+	}
+
+	var what string
+	switch e.Kind {
+	case EventArrived:
+		what = "przyjechał"
+	case EventRefuelStarted:
+		what = fmt.Sprintf("podjechał do dystrybutora %d", e.Pump)
+	case EventRefuelInterrupted:
+		what = fmt.Sprintf("awaria dystrybutora %d po %.1f L, wraca do kolejki", e.Pump, e.Amount)
+	case EventRefuelFinished:
+		what = fmt.Sprintf("zatankował %.1f L, idzie do kasy", e.Amount)
+		if reason, ok := stopReasonTexts[e.Reason]; ok {
+			what += " (" + reason + ")"
+		}
+	case EventPaid:
+		what = fmt.Sprintf("zapłacił %.2f PLN (%s), zwolnił dystrybutor %d", e.Cost, paymentMethodNames[e.Payment], e.Pump)
+	case EventLeft:
+		what = "odjechał bez tankowania: " + leftReasonTexts[e.Reason]
+	case EventShopPaid:
+		what = fmt.Sprintf("zapłacił w sklepie %.2f PLN", e.Cost)
+	case EventWashed:
+		what = fmt.Sprintf("umyty (%.2f PLN)", e.Cost)
+	default:
+		what = e.Kind
+	}
+	return fmt.Sprintf("%s  #%d %s, %s: %s", at, e.VehicleID, vehicleTypeNames[e.VehicleType], fuel, what)
+}
+
+// monitorStatistics co statusLogPeriod wypisuje w logu zdarzeń stan stacji
+func (gs *GasStation) monitorStatistics(ctx context.Context) {
+	ticker := time.NewTicker(statusLogPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		queued := gs.Queue.Len()
+		gs.Stats.mutex.RLock()
+		fmt.Printf("%s  stan: w kolejce %d, obsłużone %d z %d, zrezygnowali %d, przychód %.2f PLN\n",
+			gs.Clock.Now().Format("15:04:05.000"), queued, gs.Stats.ServedVehicles, gs.Stats.TotalVehicles,
+			gs.Stats.BalkedVehicles+gs.Stats.RenegedVehicles, gs.Stats.TotalRevenue)
+		gs.Stats.mutex.RUnlock()
+	}
+}